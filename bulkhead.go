@@ -0,0 +1,119 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrBulkheadFull is returned when a host already has the max number of requests in flight and either the wait
+// queue is full or the request waited in it for longer than the queue timeout
+var ErrBulkheadFull = errors.New("bulkhead full")
+
+// bulkhead caps the number of in-flight requests to a single host
+type bulkhead struct {
+	slots  chan struct{}
+	queued int64
+}
+
+// bulkheads holds the bulkhead settings of a Client and a bulkhead per host
+type bulkheads struct {
+	mu            sync.Mutex
+	maxConcurrent int
+	maxQueue      int64
+	queueTimeout  time.Duration
+	hosts         map[string]*bulkhead
+}
+
+// forHost returns the bulkhead of host, creating it on first use
+func (b *bulkheads) forHost(host string) *bulkhead {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	hostBulkhead, ok := b.hosts[host]
+	if !ok {
+		hostBulkhead = &bulkhead{slots: make(chan struct{}, b.maxConcurrent)}
+		b.hosts[host] = hostBulkhead
+	}
+
+	return hostBulkhead
+}
+
+// acquire takes an in-flight slot for request, waiting in the queue if none is free
+func (b *bulkheads) acquire(request *http.Request) (release func(), err error) {
+	hostBulkhead := b.forHost(request.URL.Host)
+	release = func() { <-hostBulkhead.slots }
+
+	select {
+	case hostBulkhead.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	if atomic.AddInt64(&hostBulkhead.queued, 1) > b.maxQueue {
+		atomic.AddInt64(&hostBulkhead.queued, -1)
+		return nil, ErrBulkheadFull
+	}
+	defer atomic.AddInt64(&hostBulkhead.queued, -1)
+
+	var timeout <-chan time.Time
+	if b.queueTimeout > 0 {
+		timer := time.NewTimer(b.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case hostBulkhead.slots <- struct{}{}:
+		return release, nil
+	case <-timeout:
+		return nil, ErrBulkheadFull
+	case <-request.Context().Done():
+		return nil, request.Context().Err()
+	}
+}
+
+// bulkheadTransport keeps each host under its max number of in-flight requests. A request stays in flight until
+// its response body is read to the end or closed
+type bulkheadTransport struct {
+	next      http.RoundTripper
+	bulkheads *bulkheads
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *bulkheadTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	release, err := t.bulkheads.acquire(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		release()
+		return response, err
+	}
+
+	response.Body = newCallbackBody(response.Body, release)
+
+	return response, nil
+}
+
+// SetBulkhead caps the number of in-flight requests per host to maxConcurrent. Requests over the cap wait in a queue
+// of at most maxQueue requests for up to queueTimeout, after which they fail with ErrBulkheadFull. A queueTimeout of
+// 0 means queued requests wait until their context is done. A maxConcurrent of 0 removes the bulkhead
+func (c *Client) SetBulkhead(maxConcurrent, maxQueue int, queueTimeout time.Duration) {
+	if maxConcurrent <= 0 {
+		c.bulkheads = nil
+		return
+	}
+
+	c.bulkheads = &bulkheads{
+		maxConcurrent: maxConcurrent,
+		maxQueue:      int64(maxQueue),
+		queueTimeout:  queueTimeout,
+		hosts:         make(map[string]*bulkhead),
+	}
+}
@@ -0,0 +1,74 @@
+package requestor
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_SetBulkhead(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("ok"))
+	}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetBulkhead(1, 1, 50*time.Millisecond)
+
+	inFlight, err := client.Get(testServer.URL, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	start := time.Now()
+	_, err = client.Get(testServer.URL, nil, nil)
+	if !errors.Is(err, ErrBulkheadFull) {
+		t.Errorf("Expected: %v \n Got: %v", ErrBulkheadFull, err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected request to wait for the queue timeout, waited %s", elapsed)
+	}
+
+	inFlight.Body.Close()
+
+	resp, err := client.Get(testServer.URL, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	resp.Body.Close()
+}
+
+func TestClient_SetBulkhead_QueueFull(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetBulkhead(1, 0, time.Second)
+
+	inFlight, err := client.Get(testServer.URL, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer inFlight.Body.Close()
+
+	start := time.Now()
+	_, err = client.Get(testServer.URL, nil, nil)
+	if !errors.Is(err, ErrBulkheadFull) {
+		t.Errorf("Expected: %v \n Got: %v", ErrBulkheadFull, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected request to fail without queueing, waited %s", elapsed)
+	}
+
+	client.SetBulkhead(0, 0, 0)
+	if client.bulkheads != nil {
+		t.Error("Expected bulkhead to be removed")
+	}
+}
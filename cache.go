@@ -0,0 +1,526 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// heuristicallyCacheable are the status codes which may be cached without explicit freshness information
+var heuristicallyCacheable = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusPermanentRedirect:    true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// cacheControl holds the directives of Cache-Control headers, directives without argument map to an empty string
+type cacheControl map[string]string
+
+// parseCacheControl parses every Cache-Control header of header
+func parseCacheControl(header http.Header) cacheControl {
+	directives := cacheControl{}
+
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+
+			name, argument := part, ""
+			if i := strings.Index(part, "="); i >= 0 {
+				name, argument = part[:i], strings.Trim(strings.TrimSpace(part[i+1:]), `"`)
+			}
+
+			directives[strings.ToLower(strings.TrimSpace(name))] = argument
+		}
+	}
+
+	return directives
+}
+
+// has reports whether directive is present
+func (c cacheControl) has(directive string) bool {
+	_, ok := c[directive]
+	return ok
+}
+
+// duration returns the value of a directive holding a number of seconds
+func (c cacheControl) duration(directive string) (time.Duration, bool) {
+	argument, ok := c[directive]
+	if !ok {
+		return 0, false
+	}
+
+	seconds, err := strconv.ParseInt(argument, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
+
+// cacheEntry is a stored response along with the request and response times needed to compute its age
+type cacheEntry struct {
+	StatusCode   int                 `json:"status_code"`
+	Header       http.Header         `json:"header"`
+	Body         []byte              `json:"body"`
+	Vary         map[string][]string `json:"vary,omitempty"`
+	RequestTime  time.Time           `json:"request_time"`
+	ResponseTime time.Time           `json:"response_time"`
+}
+
+// matches reports whether request selects the same variant as the one the entry was stored for
+func (e *cacheEntry) matches(request *http.Request) bool {
+	for field, values := range e.Vary {
+		if strings.Join(request.Header.Values(field), ",") != strings.Join(values, ",") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// age returns the current age of the entry as defined in RFC 9111 section 4.2.3
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	date, err := http.ParseTime(e.Header.Get("Date"))
+	if err != nil {
+		date = e.ResponseTime
+	}
+
+	apparentAge := e.ResponseTime.Sub(date)
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+
+	var ageValue time.Duration
+	if seconds, err := strconv.ParseInt(e.Header.Get("Age"), 10, 64); err == nil && seconds > 0 {
+		ageValue = time.Duration(seconds) * time.Second
+	}
+
+	correctedAge := ageValue + e.ResponseTime.Sub(e.RequestTime)
+	if apparentAge > correctedAge {
+		correctedAge = apparentAge
+	}
+
+	return correctedAge + now.Sub(e.ResponseTime)
+}
+
+// freshnessLifetime returns how long the entry stays fresh as defined in RFC 9111 section 4.2.1
+func (e *cacheEntry) freshnessLifetime(directives cacheControl, shared bool) time.Duration {
+	if shared {
+		if lifetime, ok := directives.duration("s-maxage"); ok {
+			return lifetime
+		}
+	}
+
+	if lifetime, ok := directives.duration("max-age"); ok {
+		return lifetime
+	}
+
+	date, err := http.ParseTime(e.Header.Get("Date"))
+	if err != nil {
+		date = e.ResponseTime
+	}
+
+	if expiresHeader := e.Header.Get("Expires"); expiresHeader != "" {
+		expires, err := http.ParseTime(expiresHeader)
+		if err != nil || expires.Before(date) {
+			return 0
+		}
+
+		return expires.Sub(date)
+	}
+
+	// Heuristic freshness of 10% of the time since the last modification, as suggested by RFC 9111 section 4.2.2
+	if lastModified, err := http.ParseTime(e.Header.Get("Last-Modified")); err == nil &&
+		heuristicallyCacheable[e.StatusCode] && date.After(lastModified) {
+		return date.Sub(lastModified) / 10
+	}
+
+	return 0
+}
+
+// toResponse builds a response to request out of the entry
+func (e *cacheEntry) toResponse(request *http.Request, now time.Time) *http.Response {
+	header := e.Header.Clone()
+	header.Set("Age", strconv.FormatInt(int64(e.age(now)/time.Second), 10))
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       request,
+	}
+}
+
+// responseCache implements the caching rules of RFC 9111 on top of a Cache
+type responseCache struct {
+	cache  Cache
+	shared bool
+
+	mu           sync.Mutex
+	revalidating map[string]bool
+}
+
+// cacheKey returns the key a response to request is stored under
+func cacheKey(request *http.Request) string {
+	return http.MethodGet + " " + request.URL.String()
+}
+
+// load returns the entry stored under key, or nil if there is none for the variant request selects
+func (r *responseCache) load(key string, request *http.Request) *cacheEntry {
+	value, ok := r.cache.Get(key)
+	if !ok {
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(value, &entry); err != nil || !entry.matches(request) {
+		return nil
+	}
+
+	return &entry
+}
+
+// save stores entry under key
+func (r *responseCache) save(key string, entry *cacheEntry) {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	r.cache.Set(key, value)
+}
+
+// storable reports whether response to request may be stored, following RFC 9111 section 3
+func (r *responseCache) storable(request *http.Request, response *http.Response) bool {
+	requestDirectives := parseCacheControl(request.Header)
+	directives := parseCacheControl(response.Header)
+
+	if request.Method != http.MethodGet || requestDirectives.has("no-store") || directives.has("no-store") {
+		return false
+	}
+
+	if response.StatusCode < 200 || response.StatusCode == http.StatusPartialContent ||
+		response.StatusCode == http.StatusNotModified || response.Header.Get("Vary") == "*" {
+		return false
+	}
+
+	if r.shared {
+		if directives.has("private") {
+			return false
+		}
+
+		if request.Header.Get("Authorization") != "" && !directives.has("public") &&
+			!directives.has("s-maxage") && !directives.has("must-revalidate") {
+			return false
+		}
+	}
+
+	return heuristicallyCacheable[response.StatusCode] || directives.has("public") || directives.has("max-age") ||
+		(r.shared && directives.has("s-maxage")) || response.Header.Get("Expires") != ""
+}
+
+// store saves response to request when it may be stored
+func (r *responseCache) store(request *http.Request, response *http.Response, requestTime time.Time) (*http.Response, error) {
+	if !r.storable(request, response) {
+		return response, nil
+	}
+
+	return r.storeKey(cacheKey(request), request, response, requestTime)
+}
+
+// storeKey saves response to request under key. The body is read into memory and replaced so the caller can still
+// read it
+func (r *responseCache) storeKey(key string, request *http.Request, response *http.Response, requestTime time.Time) (*http.Response, error) {
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	entry := &cacheEntry{
+		StatusCode:   response.StatusCode,
+		Header:       response.Header.Clone(),
+		Body:         body,
+		RequestTime:  requestTime,
+		ResponseTime: time.Now(),
+	}
+
+	for _, field := range response.Header.Values("Vary") {
+		for _, name := range strings.Split(field, ",") {
+			if name = http.CanonicalHeaderKey(strings.TrimSpace(name)); name != "" {
+				if entry.Vary == nil {
+					entry.Vary = make(map[string][]string)
+				}
+				entry.Vary[name] = request.Header.Values(name)
+			}
+		}
+	}
+
+	r.save(key, entry)
+
+	return response, nil
+}
+
+// freshen updates entry with the headers of a 304 Not Modified response and stores it again under key
+func (r *responseCache) freshen(key string, entry *cacheEntry, notModified *http.Response, requestTime time.Time) {
+	for field, values := range notModified.Header {
+		if field == "Content-Length" {
+			continue
+		}
+		entry.Header[field] = values
+	}
+
+	entry.RequestTime = requestTime
+	entry.ResponseTime = time.Now()
+	r.save(key, entry)
+}
+
+// withValidators returns a copy of request asking the server to only send the response if it differs from entry.
+// Requests already carrying their own conditions are returned as is
+func withValidators(request *http.Request, entry *cacheEntry) (*http.Request, bool) {
+	if request.Header.Get("If-None-Match") != "" || request.Header.Get("If-Modified-Since") != "" {
+		return request, false
+	}
+
+	etag, lastModified := entry.Header.Get("ETag"), entry.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return request, false
+	}
+
+	conditional := request.Clone(request.Context())
+	if etag != "" {
+		conditional.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	return conditional, true
+}
+
+// revalidate asks the server whether entry is still valid and updates the cache with the answer
+func (r *responseCache) revalidate(next http.RoundTripper, request *http.Request, entry *cacheEntry) {
+	conditional, _ := withValidators(request, entry)
+	requestTime := time.Now()
+
+	response, err := next.RoundTrip(conditional)
+	if err != nil {
+		return
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified {
+		r.freshen(cacheKey(request), entry, response, requestTime)
+		return
+	}
+
+	if response.StatusCode < http.StatusInternalServerError {
+		r.store(request, response, requestTime)
+	}
+}
+
+// revalidateInBackground revalidates entry without making the caller wait, at most once at a time per key
+func (r *responseCache) revalidateInBackground(next http.RoundTripper, request *http.Request, entry *cacheEntry) {
+	key := cacheKey(request)
+
+	r.mu.Lock()
+	if r.revalidating[key] {
+		r.mu.Unlock()
+		return
+	}
+	r.revalidating[key] = true
+	r.mu.Unlock()
+
+	background := request.Clone(context.WithValue(context.Background(), callStateKey{}, &callState{}))
+
+	go func() {
+		defer func() {
+			r.mu.Lock()
+			delete(r.revalidating, key)
+			r.mu.Unlock()
+		}()
+
+		r.revalidate(next, background, entry)
+	}()
+}
+
+// cacheTransport serves GET requests from the response cache when allowed and stores cacheable responses
+type cacheTransport struct {
+	next  http.RoundTripper
+	cache *responseCache
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *cacheTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	state := stateFromContext(request.Context())
+	state.setFromCache(false)
+	state.setRevalidated(false)
+
+	if request.Method != http.MethodGet {
+		response, err := t.next.RoundTrip(request)
+		if err == nil && request.Method != http.MethodHead && request.Method != http.MethodOptions &&
+			request.Method != http.MethodTrace && response.StatusCode < http.StatusBadRequest {
+			t.cache.cache.Delete(cacheKey(request))
+		}
+
+		return response, err
+	}
+
+	requestDirectives := parseCacheControl(request.Header)
+	if requestDirectives.has("no-store") || request.Header.Get("Range") != "" {
+		return t.next.RoundTrip(request)
+	}
+
+	now := time.Now()
+	entry := t.cache.load(cacheKey(request), request)
+
+	var directives cacheControl
+	if entry != nil {
+		directives = parseCacheControl(entry.Header)
+		age, lifetime := entry.age(now), entry.freshnessLifetime(directives, t.cache.shared)
+
+		if maxAge, ok := requestDirectives.duration("max-age"); ok && maxAge < lifetime {
+			lifetime = maxAge
+		}
+
+		noCache := requestDirectives.has("no-cache") || directives.has("no-cache") ||
+			(len(requestDirectives) == 0 && request.Header.Get("Pragma") == "no-cache")
+
+		if !noCache && age < lifetime {
+			state.setFromCache(true)
+			return entry.toResponse(request, now), nil
+		}
+
+		if window, ok := directives.duration("stale-while-revalidate"); ok && !noCache &&
+			!t.mustRevalidate(directives) && age-lifetime <= window {
+			t.cache.revalidateInBackground(t.next, request, entry)
+			state.setFromCache(true)
+			return entry.toResponse(request, now), nil
+		}
+	} else if requestDirectives.has("only-if-cached") {
+		return &http.Response{
+			Status:     fmt.Sprintf("%d %s", http.StatusGatewayTimeout, http.StatusText(http.StatusGatewayTimeout)),
+			StatusCode: http.StatusGatewayTimeout,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       http.NoBody,
+			Request:    request,
+		}, nil
+	}
+
+	outgoing, conditional := request, false
+	if entry != nil {
+		outgoing, conditional = withValidators(request, entry)
+	}
+
+	requestTime := time.Now()
+	response, err := t.next.RoundTrip(outgoing)
+
+	if entry != nil && (err != nil || response.StatusCode >= http.StatusInternalServerError) &&
+		t.staleIfError(entry, directives, requestDirectives, now) {
+		if err == nil {
+			response.Body.Close()
+		}
+
+		state.setFromCache(true)
+		return entry.toResponse(request, time.Now()), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if conditional && response.StatusCode == http.StatusNotModified {
+		response.Body.Close()
+		t.cache.freshen(cacheKey(request), entry, response, requestTime)
+
+		state.setFromCache(true)
+		state.setRevalidated(true)
+		return entry.toResponse(request, time.Now()), nil
+	}
+
+	return t.cache.store(request, response, requestTime)
+}
+
+// mustRevalidate reports whether the response forbids serving it once stale
+func (t *cacheTransport) mustRevalidate(directives cacheControl) bool {
+	return directives.has("must-revalidate") || (t.cache.shared && directives.has("proxy-revalidate"))
+}
+
+// staleIfError reports whether entry may be served instead of an error as allowed by the stale-if-error directive
+// of RFC 5861
+func (t *cacheTransport) staleIfError(entry *cacheEntry, directives, requestDirectives cacheControl, now time.Time) bool {
+	if t.mustRevalidate(directives) {
+		return false
+	}
+
+	window, ok := requestDirectives.duration("stale-if-error")
+	if !ok {
+		window, ok = directives.duration("stale-if-error")
+	}
+	if !ok {
+		return false
+	}
+
+	return entry.age(now)-entry.freshnessLifetime(directives, t.cache.shared) <= window
+}
+
+// FromCache reports whether response was served from the response cache of the Client
+func FromCache(response *http.Response) bool {
+	if response == nil || response.Request == nil {
+		return false
+	}
+
+	state := stateFromContext(response.Request.Context())
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return state.fromCache
+}
+
+// SetCache enables the response cache of the Client, storing cacheable GET responses in cache and honouring
+// Cache-Control, Expires and Vary as defined in RFC 9111 along with the stale-while-revalidate and stale-if-error
+// extensions. A nil cache disables caching
+func (c *Client) SetCache(cache Cache) {
+	if cache == nil {
+		c.responseCache = nil
+		return
+	}
+
+	c.responseCache = &responseCache{cache: cache, revalidating: make(map[string]bool)}
+}
+
+// SetSharedCache makes the response cache behave as a shared cache, honouring s-maxage and proxy-revalidate and not
+// storing private responses. By default it behaves as a private cache. SetCache has to be called first
+func (c *Client) SetSharedCache(val bool) {
+	if c.responseCache != nil {
+		c.responseCache.shared = val
+	}
+}
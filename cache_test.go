@@ -0,0 +1,239 @@
+package requestor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newCountingTestServer(hits *int32, handler func(writer http.ResponseWriter, request *http.Request)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(hits, 1)
+		handler(writer, request)
+	}))
+}
+
+func TestClient_SetCache_Freshness(t *testing.T) {
+	testCases := []struct {
+		name         string
+		cacheControl string
+		expires      string
+		shared       bool
+		expectedHits int32
+	}{
+		{"max-age", "max-age=60", "", false, 1},
+		{"no-store", "max-age=60, no-store", "", false, 2},
+		{"no-cache", "no-cache", "", false, 2},
+		{"expires", "", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), false, 1},
+		{"expired", "", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), false, 2},
+		{"s-maxage private cache", "s-maxage=60", "", false, 2},
+		{"s-maxage shared cache", "s-maxage=60", "", true, 1},
+		{"private shared cache", "private, max-age=60", "", true, 2},
+	}
+
+	for _, testCase := range testCases {
+		var hits int32
+		testServer := newCountingTestServer(&hits, func(writer http.ResponseWriter, request *http.Request) {
+			if testCase.cacheControl != "" {
+				writer.Header().Set("Cache-Control", testCase.cacheControl)
+			}
+			if testCase.expires != "" {
+				writer.Header().Set("Expires", testCase.expires)
+			}
+			writer.Write([]byte("cached"))
+		})
+
+		client := New()
+		client.SetCache(NewMemoryCache(10))
+		client.SetSharedCache(testCase.shared)
+
+		for i := 0; i < 2; i++ {
+			resp, err := client.Get(testServer.URL, nil, nil)
+			if err != nil {
+				t.Error(err)
+				break
+			}
+
+			if body := readBody(t, resp); body != "cached" {
+				t.Errorf("%s: Expected: %s \n Got: %s", testCase.name, "cached", body)
+			}
+
+			if i == 1 && FromCache(resp) != (testCase.expectedHits == 1) {
+				t.Errorf("%s: Expected: %t \n Got: %t", testCase.name, testCase.expectedHits == 1, FromCache(resp))
+			}
+		}
+
+		if hits != testCase.expectedHits {
+			t.Errorf("%s: Expected: %d \n Got: %d", testCase.name, testCase.expectedHits, hits)
+		}
+
+		testServer.Close()
+	}
+}
+
+func TestClient_SetCache_Vary(t *testing.T) {
+	var hits int32
+	testServer := newCountingTestServer(&hits, func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Cache-Control", "max-age=60")
+		writer.Header().Set("Vary", "Accept")
+		writer.Write([]byte(request.Header.Get("Accept")))
+	})
+	defer testServer.Close()
+
+	client := New()
+	client.SetCache(NewMemoryCache(10))
+
+	for _, accept := range []string{"text/plain", "text/plain", "application/json"} {
+		resp, err := client.Get(testServer.URL, map[string][]string{"Accept": {accept}}, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if body := readBody(t, resp); body != accept {
+			t.Errorf("Expected: %s \n Got: %s", accept, body)
+		}
+	}
+
+	if hits != 2 {
+		t.Errorf("Expected: %d \n Got: %d", 2, hits)
+	}
+}
+
+func TestClient_SetCache_Revalidation(t *testing.T) {
+	var hits, notModified int32
+	testServer := newCountingTestServer(&hits, func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Cache-Control", "max-age=0")
+		writer.Header().Set("ETag", `"v1"`)
+		if request.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+		writer.Write([]byte("body"))
+	})
+	defer testServer.Close()
+
+	client := New()
+	client.SetCache(NewMemoryCache(10))
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(testServer.URL, nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected: %d \n Got: %d", http.StatusOK, resp.StatusCode)
+		}
+
+		if body := readBody(t, resp); body != "body" {
+			t.Errorf("Expected: %s \n Got: %s", "body", body)
+		}
+	}
+
+	if hits != 3 || notModified != 2 {
+		t.Errorf("Expected 3 requests with 2 revalidations, got %d requests and %d revalidations", hits, notModified)
+	}
+}
+
+func TestClient_SetCache_StaleIfError(t *testing.T) {
+	var hits int32
+	testServer := newCountingTestServer(&hits, func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.LoadInt32(&hits) > 1 {
+			writer.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		writer.Header().Set("Cache-Control", "max-age=0, stale-if-error=60")
+		writer.Write([]byte("stale"))
+	})
+	defer testServer.Close()
+
+	client := New()
+	client.SetCache(NewMemoryCache(10))
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(testServer.URL, nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if body := readBody(t, resp); body != "stale" {
+			t.Errorf("Expected: %s \n Got: %s", "stale", body)
+		}
+	}
+
+	if hits != 2 {
+		t.Errorf("Expected: %d \n Got: %d", 2, hits)
+	}
+}
+
+func TestClient_SetCache_StaleWhileRevalidate(t *testing.T) {
+	var hits int32
+	testServer := newCountingTestServer(&hits, func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Cache-Control", "max-age=0, stale-while-revalidate=60")
+		writer.Write([]byte("body"))
+	})
+	defer testServer.Close()
+
+	client := New()
+	client.SetCache(NewMemoryCache(10))
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(testServer.URL, nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		readBody(t, resp)
+
+		if i == 1 && !FromCache(resp) {
+			t.Error("Expected stale response to be served from cache")
+		}
+	}
+
+	waitFor(t, func() bool { return atomic.LoadInt32(&hits) == 2 })
+}
+
+func TestClient_SetCache_Invalidation(t *testing.T) {
+	var hits int32
+	testServer := newCountingTestServer(&hits, func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Cache-Control", "max-age=60")
+	})
+	defer testServer.Close()
+
+	cache := NewMemoryCache(10)
+	client := New()
+	client.SetCache(cache)
+
+	resp, err := client.Get(testServer.URL, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	readBody(t, resp)
+
+	if cache.Len() != 1 {
+		t.Errorf("Expected: %d \n Got: %d", 1, cache.Len())
+	}
+
+	resp, err = client.Post(testServer.URL, nil, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	readBody(t, resp)
+
+	if cache.Len() != 0 {
+		t.Errorf("Expected: %d \n Got: %d", 0, cache.Len())
+	}
+
+	client.SetCache(nil)
+	if client.responseCache != nil {
+		t.Error("Expected cache to be disabled")
+	}
+}
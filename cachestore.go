@@ -0,0 +1,151 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Cache is the storage behind the response cache of a Client. Implementations have to be safe for concurrent use
+type Cache interface {
+	// Get returns the value stored under key and whether it was found
+	Get(key string) ([]byte, bool)
+	// Set stores value under key
+	Set(key string, value []byte)
+	// Delete removes key from the cache
+	Delete(key string)
+}
+
+// memoryCacheItem is a single entry of a MemoryCache
+type memoryCacheItem struct {
+	key   string
+	value []byte
+}
+
+// MemoryCache is an in-memory Cache which evicts the least recently used entry once it holds MaxEntries entries
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	items      map[string]*list.Element
+	order      *list.List
+}
+
+// NewMemoryCache creates a MemoryCache holding up to maxEntries entries, 0 means no limit
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		items:      make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get implements Cache
+func (m *MemoryCache) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	m.order.MoveToFront(element)
+
+	return element.Value.(*memoryCacheItem).value, true
+}
+
+// Set implements Cache
+func (m *MemoryCache) Set(key string, value []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.items[key]; ok {
+		element.Value.(*memoryCacheItem).value = value
+		m.order.MoveToFront(element)
+		return
+	}
+
+	m.items[key] = m.order.PushFront(&memoryCacheItem{key: key, value: value})
+
+	if m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+// Delete implements Cache
+func (m *MemoryCache) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.items[key]; ok {
+		m.order.Remove(element)
+		delete(m.items, key)
+	}
+}
+
+// Len returns the number of entries in the cache
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.order.Len()
+}
+
+// DiskCache is a Cache storing every entry as a file in a directory, so cached responses survive restarts
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a DiskCache in dir, creating the directory if needed
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &DiskCache{dir: dir}, nil
+}
+
+// path returns the file an entry is stored in
+func (d *DiskCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+// Get implements Cache
+func (d *DiskCache) Get(key string) ([]byte, bool) {
+	value, err := ioutil.ReadFile(d.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// Set implements Cache. The entry is written to a temporary file first so readers never see a partial entry
+func (d *DiskCache) Set(key string, value []byte) {
+	file, err := ioutil.TempFile(d.dir, "tmp-")
+	if err != nil {
+		return
+	}
+
+	_, err = file.Write(value)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil || os.Rename(file.Name(), d.path(key)) != nil {
+		os.Remove(file.Name())
+	}
+}
+
+// Delete implements Cache
+func (d *DiskCache) Delete(key string) {
+	os.Remove(d.path(key))
+}
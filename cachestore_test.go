@@ -0,0 +1,46 @@
+package requestor
+
+import (
+	"testing"
+)
+
+func TestMemoryCache(t *testing.T) {
+	cache := NewMemoryCache(2)
+
+	cache.Set("a", []byte("1"))
+	cache.Set("b", []byte("2"))
+	cache.Get("a")
+	cache.Set("c", []byte("3"))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Expected least recently used entry to be evicted")
+	}
+
+	if value, ok := cache.Get("a"); !ok || string(value) != "1" {
+		t.Errorf("Expected: %s \n Got: %s", "1", value)
+	}
+
+	cache.Delete("a")
+	if cache.Len() != 1 {
+		t.Errorf("Expected: %d \n Got: %d", 1, cache.Len())
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	cache, err := NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	cache.Set("GET http://example.com", []byte("entry"))
+
+	if value, ok := cache.Get("GET http://example.com"); !ok || string(value) != "entry" {
+		t.Errorf("Expected: %s \n Got: %s", "entry", value)
+	}
+
+	cache.Delete("GET http://example.com")
+	if _, ok := cache.Get("GET http://example.com"); ok {
+		t.Error("Expected entry to be deleted")
+	}
+}
@@ -0,0 +1,344 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrInteractionNotFound is returned in replay mode when no recorded interaction matches a request
+var ErrInteractionNotFound = errors.New("no recorded interaction matches the request")
+
+// CassetteMode tells whether a Cassette records interactions or replays them
+type CassetteMode int
+
+const (
+	// CassetteRecord sends requests over the network and records every interaction to the cassette file
+	CassetteRecord CassetteMode = iota
+	// CassetteReplay serves requests from the cassette file without touching the network
+	CassetteReplay
+)
+
+// CassetteRequest is a recorded request
+type CassetteRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+	// BodyEncoding is base64 for binary bodies
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+// CassetteResponse is a recorded response
+type CassetteResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+	// BodyEncoding is base64 for binary bodies
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+// Interaction is a request recorded along with its response
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// Matcher tells whether request, whose body is body, matches a recorded request
+type Matcher func(request *http.Request, body []byte, recorded CassetteRequest) bool
+
+// MatchMethod matches requests with the same method
+func MatchMethod(request *http.Request, body []byte, recorded CassetteRequest) bool {
+	return request.Method == recorded.Method
+}
+
+// MatchURL matches requests with the same URL, query included
+func MatchURL(request *http.Request, body []byte, recorded CassetteRequest) bool {
+	return request.URL.String() == recorded.URL
+}
+
+// MatchBody matches requests with the same body
+func MatchBody(request *http.Request, body []byte, recorded CassetteRequest) bool {
+	recordedBody, err := decodeCassetteBody(recorded.Body, recorded.BodyEncoding)
+	return err == nil && bytes.Equal(body, recordedBody)
+}
+
+// MatchHeaders returns a Matcher matching requests with the same values for the given headers
+func MatchHeaders(headers ...string) Matcher {
+	return func(request *http.Request, body []byte, recorded CassetteRequest) bool {
+		for _, header := range headers {
+			values, recordedValues := request.Header.Values(header), recorded.Header.Values(header)
+			if len(values) != len(recordedValues) {
+				return false
+			}
+
+			for i := range values {
+				if values[i] != recordedValues[i] {
+					return false
+				}
+			}
+		}
+
+		return true
+	}
+}
+
+// encodeCassetteBody encodes body as text, falling back to base64 for binary bodies
+func encodeCassetteBody(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// decodeCassetteBody decodes a body encoded by encodeCassetteBody
+func decodeCassetteBody(body, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(body)
+	}
+
+	return []byte(body), nil
+}
+
+// Cassette holds the interactions of a Client with the outside world in a JSON file, so tests can record them once
+// and replay them later without the network
+type Cassette struct {
+	path     string
+	mode     CassetteMode
+	matchers []Matcher
+	scrubbed map[string]bool
+
+	mu           sync.Mutex
+	interactions []Interaction
+	played       []bool
+}
+
+// NewCassette creates a Cassette stored at path. In CassetteReplay mode the file is loaded and must exist, in
+// CassetteRecord mode it is overwritten with the interactions recorded from now on. Requests match a recorded one
+// when they have the same method and URL, see SetMatchers
+func NewCassette(path string, mode CassetteMode) (*Cassette, error) {
+	cassette := &Cassette{
+		path:     path,
+		mode:     mode,
+		matchers: []Matcher{MatchMethod, MatchURL},
+		scrubbed: make(map[string]bool),
+	}
+
+	if mode == CassetteReplay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(data, &cassette.interactions); err != nil {
+			return nil, err
+		}
+		cassette.played = make([]bool, len(cassette.interactions))
+	}
+
+	return cassette, nil
+}
+
+// SetMatchers replaces the matchers deciding which recorded interaction serves a request, all of them must match
+func (c *Cassette) SetMatchers(matchers ...Matcher) {
+	c.matchers = matchers
+}
+
+// ScrubHeaders hides the values of the given headers, in requests and responses, from the recorded interactions.
+// Authorization, Proxy-Authorization, Cookie and Set-Cookie are always scrubbed
+func (c *Cassette) ScrubHeaders(headers ...string) {
+	for _, header := range headers {
+		c.scrubbed[http.CanonicalHeaderKey(header)] = true
+	}
+}
+
+// Interactions returns the interactions of the cassette
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	interactions := make([]Interaction, len(c.interactions))
+	copy(interactions, c.interactions)
+
+	return interactions
+}
+
+// scrub returns a copy of header with the scrubbed headers hidden
+func (c *Cassette) scrub(header http.Header) http.Header {
+	scrubbed := header.Clone()
+	if scrubbed == nil {
+		scrubbed = make(http.Header)
+	}
+
+	for field := range scrubbed {
+		if c.scrubbed[field] {
+			scrubbed[field] = []string{redacted}
+		}
+	}
+
+	for _, field := range defaultRedactedHeaders {
+		if _, ok := scrubbed[field]; ok {
+			scrubbed[field] = []string{redacted}
+		}
+	}
+
+	return scrubbed
+}
+
+// replay returns the response of the first interaction matching request which was not played yet
+func (c *Cassette) replay(request *http.Request) (*http.Response, error) {
+	body, request, err := requestBodyBytes(request)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, interaction := range c.interactions {
+		if c.played[i] || !c.matches(request, body, interaction.Request) {
+			continue
+		}
+
+		responseBody, err := decodeCassetteBody(interaction.Response.Body, interaction.Response.BodyEncoding)
+		if err != nil {
+			return nil, err
+		}
+		c.played[i] = true
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader(responseBody)),
+			ContentLength: int64(len(responseBody)),
+			Request:       request,
+		}, nil
+	}
+
+	return nil, ErrInteractionNotFound
+}
+
+// matches tells whether request matches recorded according to every matcher. The caller must hold the lock
+func (c *Cassette) matches(request *http.Request, body []byte, recorded CassetteRequest) bool {
+	for _, matcher := range c.matchers {
+		if !matcher(request, body, recorded) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// record adds an interaction to the cassette and saves it
+func (c *Cassette) record(request *http.Request, requestBody []byte, response *http.Response, responseBody []byte) error {
+	interaction := Interaction{
+		Request: CassetteRequest{
+			Method: request.Method,
+			URL:    request.URL.String(),
+			Header: c.scrub(request.Header),
+		},
+		Response: CassetteResponse{
+			Status: response.StatusCode,
+			Header: c.scrub(response.Header),
+		},
+	}
+	interaction.Request.Body, interaction.Request.BodyEncoding = encodeCassetteBody(requestBody)
+	interaction.Response.Body, interaction.Response.BodyEncoding = encodeCassetteBody(responseBody)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, interaction)
+
+	return c.save()
+}
+
+// save writes the interactions to the cassette file. The caller must hold the lock
+func (c *Cassette) save() error {
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(c.path, data)
+}
+
+// writeFileAtomic writes data to path through a temporary file renamed over it, so readers never see a half written
+// file. The file is only readable by its owner
+func writeFileAtomic(path string, data []byte) error {
+	file, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(file.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(file.Name())
+	}
+
+	return err
+}
+
+// cassetteTransport records interactions to a Cassette or replays them from it
+type cassetteTransport struct {
+	next     http.RoundTripper
+	cassette *Cassette
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *cassetteTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if t.cassette.mode == CassetteReplay {
+		return t.cassette.replay(request)
+	}
+
+	requestBody, request, err := requestBodyBytes(request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(responseBody))
+
+	if err := t.cassette.record(request, requestBody, response, responseBody); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// SetCassette makes the Client record its interactions to cassette or replay them from it, depending on the mode of
+// the cassette. A nil cassette goes back to the network
+func (c *Client) SetCassette(cassette *Cassette) {
+	c.cassette = cassette
+}
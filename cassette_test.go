@@ -0,0 +1,97 @@
+package requestor
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClient_SetCassette(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "users.json")
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		writer.Header().Set("X-Session", "session-secret")
+		writer.Write([]byte("hello " + string(body)))
+	}))
+
+	cassette, err := NewCassette(path, CassetteRecord)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cassette.ScrubHeaders("X-Session")
+
+	client := New()
+	client.SetCassette(cassette)
+
+	headers := map[string][]string{"Content-Type": {"application/json"}, "Authorization": {"Bearer token-secret"}}
+	for _, name := range []string{"alice", "bob"} {
+		resp, err := client.Post(testServer.URL, headers, nil, map[string]string{"name": name})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if body := readBody(t, resp); !strings.Contains(body, name) {
+			t.Errorf("Expected the recorded response to reach the caller, got %s", body)
+		}
+	}
+	testServer.Close()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("Expected headers to be scrubbed, got %s", data)
+	}
+
+	cassette, err = NewCassette(path, CassetteReplay)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	cassette.SetMatchers(MatchMethod, MatchURL, MatchBody)
+	client.SetCassette(cassette)
+
+	for _, name := range []string{"bob", "alice"} {
+		resp, err := client.Post(testServer.URL, headers, nil, map[string]string{"name": name})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		expected := `hello {"name":"` + name + `"}`
+		if body := readBody(t, resp); body != expected {
+			t.Errorf("Expected: %s \n Got: %s", expected, body)
+		}
+
+		if resp.Status != "200 OK" {
+			t.Errorf("Expected: %s \n Got: %s", "200 OK", resp.Status)
+		}
+	}
+
+	if _, err := client.Post(testServer.URL, headers, nil, map[string]string{"name": "alice"}); !errors.Is(err, ErrInteractionNotFound) {
+		t.Errorf("Expected: %v \n Got: %v", ErrInteractionNotFound, err)
+	}
+}
+
+func TestNewCassette_MissingFile(t *testing.T) {
+	if _, err := NewCassette(filepath.Join(os.TempDir(), "missing-cassette.json"), CassetteReplay); err == nil {
+		t.Error("Expected replaying a missing cassette to fail")
+	}
+}
@@ -0,0 +1,181 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// IntegrityError is returned when downloaded content does not match its expected checksum
+type IntegrityError struct {
+	// Algorithm is md5, sha256 or sha512
+	Algorithm string
+	// Expected and Actual are hex encoded checksums
+	Expected string
+	Actual   string
+}
+
+// Error implements error
+func (e *IntegrityError) Error() string {
+	return e.Algorithm + " checksum mismatch: expected " + e.Expected + ", got " + e.Actual
+}
+
+// newHash returns a hash for algorithm, or nil if it is not supported
+func newHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case "md5":
+		return md5.New()
+	case "sha256":
+		return sha256.New()
+	case "sha512":
+		return sha512.New()
+	}
+
+	return nil
+}
+
+// normalizeAlgorithm turns the spellings of digest algorithms found in headers, like SHA-256 or sha-256, into the
+// names used by newHash
+func normalizeAlgorithm(algorithm string) string {
+	return strings.Replace(strings.ToLower(strings.TrimSpace(algorithm)), "-", "", -1)
+}
+
+// checksums maps algorithms to the checksum content is expected to have
+type checksums map[string][]byte
+
+// add records an expected checksum, ignoring unsupported algorithms and undecodable values
+func (c checksums) add(algorithm string, sum []byte, err error) {
+	algorithm = normalizeAlgorithm(algorithm)
+	if err == nil && newHash(algorithm) != nil && len(sum) == newHash(algorithm).Size() {
+		c[algorithm] = sum
+	}
+}
+
+// addDigestHeaders records the checksums found in the headers of response. Content-Digest and Content-MD5 describe
+// the body of the response, so they only count for full responses, while Digest and Repr-Digest describe the whole
+// representation. ETags are only trusted as MD5 checksums when etagIsMD5 is set
+func (c checksums) addDigestHeaders(response *http.Response, etagIsMD5 bool) {
+	full := response.StatusCode == http.StatusOK
+
+	// Digest: SHA-256=base64, MD5=base64 as defined by RFC 3230
+	for _, value := range response.Header.Values("Digest") {
+		for _, item := range strings.Split(value, ",") {
+			if parts := strings.SplitN(item, "=", 2); len(parts) == 2 {
+				sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(parts[1]))
+				c.add(parts[0], sum, err)
+			}
+		}
+	}
+
+	// Repr-Digest and Content-Digest: sha-256=:base64: as defined by RFC 9530
+	fields := []string{"Repr-Digest"}
+	if full {
+		fields = append(fields, "Content-Digest")
+	}
+	for _, field := range fields {
+		for _, value := range response.Header.Values(field) {
+			for _, item := range strings.Split(value, ",") {
+				if parts := strings.SplitN(item, "=", 2); len(parts) == 2 {
+					sum, err := base64.StdEncoding.DecodeString(strings.Trim(strings.TrimSpace(parts[1]), ":"))
+					c.add(parts[0], sum, err)
+				}
+			}
+		}
+	}
+
+	if value := response.Header.Get("Content-MD5"); full && value != "" {
+		sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+		c.add("md5", sum, err)
+	}
+
+	if etagIsMD5 {
+		c.addETag(response)
+	}
+}
+
+// addETag records the strong ETag of response as an MD5 checksum, if it looks like one
+func (c checksums) addETag(response *http.Response) {
+	if etag := response.Header.Get("ETag"); !strings.HasPrefix(etag, "W/") {
+		sum, err := hex.DecodeString(strings.Trim(etag, `"`))
+		c.add("md5", sum, err)
+	}
+}
+
+// verifyFile checks the content of the file at path against every expected checksum
+func (c checksums) verifyFile(path string) error {
+	if len(c) == 0 {
+		return nil
+	}
+
+	algorithms := make([]string, 0, len(c))
+	hashes := make(map[string]hash.Hash, len(c))
+	writers := make([]io.Writer, 0, len(c))
+	for algorithm := range c {
+		algorithms = append(algorithms, algorithm)
+		hashes[algorithm] = newHash(algorithm)
+		writers = append(writers, hashes[algorithm])
+	}
+	sort.Strings(algorithms)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(io.MultiWriter(writers...), file); err != nil {
+		return err
+	}
+
+	for _, algorithm := range algorithms {
+		if actual := hashes[algorithm].Sum(nil); !bytes.Equal(actual, c[algorithm]) {
+			return &IntegrityError{
+				Algorithm: algorithm,
+				Expected:  hex.EncodeToString(c[algorithm]),
+				Actual:    hex.EncodeToString(actual),
+			}
+		}
+	}
+
+	return nil
+}
+
+// contentDigestTransport sends the SHA-256 checksum of request bodies in the Content-Digest header
+type contentDigestTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *contentDigestTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, request, err := requestBodyBytes(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if body == nil {
+		return t.next.RoundTrip(request)
+	}
+
+	sum := sha256.Sum256(body)
+	request = request.Clone(request.Context())
+	request.Header.Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+
+	return t.next.RoundTrip(request)
+}
+
+// SetContentDigest makes the Client send the SHA-256 checksum of request bodies in the Content-Digest header defined
+// by RFC 9530, so servers can check uploads were not corrupted. The checksum covers the body as sent, after
+// SetCompression
+func (c *Client) SetContentDigest(val bool) {
+	c.contentDigest = val
+}
@@ -0,0 +1,153 @@
+package requestor
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClient_DownloadWithOptions_SHA256(t *testing.T) {
+	content := []byte("hello world")
+	sum := sha256.Sum256(content)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write(content)
+	}))
+	defer testServer.Close()
+
+	dir, err := ioutil.TempDir("", "checksum")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "hello.txt")
+
+	if err := New().DownloadWithOptions(testServer.URL, path, DownloadOptions{SHA256: hex.EncodeToString(sum[:])}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if downloaded, _ := ioutil.ReadFile(path); !bytes.Equal(downloaded, content) {
+		t.Errorf("Expected: %s \n Got: %s", content, downloaded)
+	}
+
+	wrong := sha256.Sum256([]byte("something else"))
+	err = New().DownloadWithOptions(testServer.URL, path+".bad", DownloadOptions{SHA256: hex.EncodeToString(wrong[:])})
+
+	var integrityErr *IntegrityError
+	if !errors.As(err, &integrityErr) {
+		t.Errorf("Expected an *IntegrityError, got %v", err)
+		return
+	}
+
+	if integrityErr.Algorithm != "sha256" || integrityErr.Actual != hex.EncodeToString(sum[:]) {
+		t.Errorf("Expected: sha256 %x \n Got: %s %s", sum, integrityErr.Algorithm, integrityErr.Actual)
+	}
+
+	for _, leftover := range []string{path + ".bad", path + ".bad.part"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to exist, got %v", leftover, err)
+		}
+	}
+
+	if err := New().DownloadWithOptions(testServer.URL, path, DownloadOptions{MD5: "not hex"}); err == nil {
+		t.Error("Expected an error for an invalid checksum")
+	}
+}
+
+func TestClient_DownloadWithOptions_VerifyHeaders(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghij"), 1000)
+	sha := sha256.Sum256(content)
+	md := md5.Sum([]byte("tampered"))
+
+	headers := map[string]string{
+		"Digest":      "SHA-256=" + base64.StdEncoding.EncodeToString(sha[:]),
+		"Content-MD5": base64.StdEncoding.EncodeToString(md[:]),
+	}
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		for key, value := range headers {
+			writer.Header().Set(key, value)
+		}
+		http.ServeContent(writer, request, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer testServer.Close()
+
+	dir, err := ioutil.TempDir("", "checksum")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "artifact.bin")
+
+	var integrityErr *IntegrityError
+	err = New().DownloadWithOptions(testServer.URL, path, DownloadOptions{VerifyHeaders: true})
+	if !errors.As(err, &integrityErr) || integrityErr.Algorithm != "md5" {
+		t.Errorf("Expected an md5 *IntegrityError, got %v", err)
+	}
+
+	// Without Content-MD5 the Digest header matches, segmented downloads included
+	delete(headers, "Content-MD5")
+	for _, segments := range []int{0, 4} {
+		if err := New().DownloadWithOptions(testServer.URL, path, DownloadOptions{Segments: segments, VerifyHeaders: true}); err != nil {
+			t.Error(err)
+		}
+	}
+
+	headers["ETag"] = `"` + hex.EncodeToString(md[:]) + `"`
+	err = New().DownloadWithOptions(testServer.URL, path, DownloadOptions{ETagIsMD5: true})
+	if !errors.As(err, &integrityErr) || integrityErr.Algorithm != "md5" {
+		t.Errorf("Expected an md5 *IntegrityError, got %v", err)
+	}
+}
+
+func TestClient_SetContentDigest(t *testing.T) {
+	var digest string
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		sum := sha256.Sum256(body)
+
+		digest = request.Header.Get("Content-Digest")
+		if digest != "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":" {
+			writer.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetContentDigest(true)
+
+	headers := map[string][]string{"Content-Type": {"application/json"}}
+	resp, err := client.Post(testServer.URL, headers, nil, map[string]string{"name": "requestor"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected: %d \n Got: %d (%s)", http.StatusOK, resp.StatusCode, digest)
+	}
+
+	resp, err = client.Get(testServer.URL, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	resp.Body.Close()
+
+	if digest != "" {
+		t.Errorf("Expected no Content-Digest without a body, got %s", digest)
+	}
+}
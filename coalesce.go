@@ -0,0 +1,141 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// coalescedCall is a request in flight whose result is shared by every identical request made meanwhile
+type coalescedCall struct {
+	done     chan struct{}
+	response *http.Response
+	body     []byte
+	err      error
+}
+
+// coalescer deduplicates identical concurrent requests so they share a single network call
+type coalescer struct {
+	headers []string
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// keyHeaders are always part of the key, as they ask for a different version of the response, or identify the caller,
+// whose response must never be handed to someone else
+var keyHeaders = []string{"Authorization", "Cookie", "If-Match", "If-None-Match", "If-Modified-Since",
+	"If-Unmodified-Since"}
+
+// key identifies requests which may share a call, made of the method, the URL, the credentials, the conditional
+// headers and the selected headers
+func (c *coalescer) key(request *http.Request) string {
+	var key strings.Builder
+
+	key.WriteString(request.Method)
+	key.WriteString(" ")
+	key.WriteString(request.URL.String())
+
+	for _, headers := range [][]string{keyHeaders, c.headers} {
+		for _, header := range headers {
+			key.WriteString("\n")
+			key.WriteString(header)
+			key.WriteString(": ")
+			key.WriteString(strings.Join(request.Header.Values(header), ","))
+		}
+	}
+
+	return key.String()
+}
+
+// coalescingTransport shares the response of a GET or HEAD request with every identical request made while it is in
+// flight. Each caller gets its own copy of the response with a body it can read and close independently. The shared
+// body is held in memory, so range and no-store requests, like the ones made by downloads, are sent on their own
+type coalescingTransport struct {
+	next      http.RoundTripper
+	coalescer *coalescer
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *coalescingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if (request.Method != http.MethodGet && request.Method != http.MethodHead) ||
+		(request.Body != nil && request.Body != http.NoBody) || request.Header.Get("Range") != "" ||
+		parseCacheControl(request.Header).has("no-store") {
+		return t.next.RoundTrip(request)
+	}
+
+	key := t.coalescer.key(request)
+
+	t.coalescer.mu.Lock()
+	call, inFlight := t.coalescer.calls[key]
+	if !inFlight {
+		call = &coalescedCall{done: make(chan struct{})}
+		t.coalescer.calls[key] = call
+	}
+	t.coalescer.mu.Unlock()
+
+	if !inFlight {
+		t.do(key, call, request)
+	}
+
+	select {
+	case <-call.done:
+	case <-request.Context().Done():
+		return nil, request.Context().Err()
+	}
+
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	response := *call.response
+	response.Header = call.response.Header.Clone()
+	response.Body = ioutil.NopCloser(bytes.NewReader(call.body))
+	response.ContentLength = int64(len(call.body))
+	response.Request = request
+
+	return &response, nil
+}
+
+// do performs the shared call and hands its result to every waiting request
+func (t *coalescingTransport) do(key string, call *coalescedCall, request *http.Request) {
+	defer func() {
+		t.coalescer.mu.Lock()
+		delete(t.coalescer.calls, key)
+		t.coalescer.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		call.err = err
+		return
+	}
+	defer response.Body.Close()
+
+	call.body, call.err = ioutil.ReadAll(response.Body)
+	call.response = response
+}
+
+// SetRequestCoalescing makes concurrent identical GET and HEAD requests share a single network call. Requests are
+// identical when they have the same method, URL, Authorization and Cookie, conditional headers and values for the
+// given headers, other headers are ignored, so headers changing the response, like Accept, should be listed. Range
+// and Cache-Control: no-store requests are never shared. The shared call runs with the context of the first request,
+// if it is cancelled every request sharing the call fails
+func (c *Client) SetRequestCoalescing(val bool, headers ...string) {
+	if !val {
+		c.coalescer = nil
+		return
+	}
+
+	canonicalHeaders := make([]string, 0, len(headers))
+	for _, header := range headers {
+		canonicalHeaders = append(canonicalHeaders, http.CanonicalHeaderKey(header))
+	}
+
+	c.coalescer = &coalescer{headers: canonicalHeaders, calls: make(map[string]*coalescedCall)}
+}
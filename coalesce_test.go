@@ -0,0 +1,148 @@
+package requestor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_SetRequestCoalescing(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+		writer.Write([]byte("shared " + request.Header.Get("Authorization")))
+	}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetRequestCoalescing(true)
+	httpClient := client.newHTTPClient()
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 10)
+
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			token := "a"
+			if i == 0 {
+				token = "b"
+			}
+
+			request, _ := http.NewRequest(http.MethodGet, testServer.URL, nil)
+			request.Header.Set("Authorization", token)
+
+			resp, err := httpClient.Do(request)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			bodies[i] = readBody(t, resp)
+		}(i)
+	}
+
+	waitFor(t, func() bool { return atomic.LoadInt32(&hits) == 2 })
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if hits != 2 {
+		t.Errorf("Expected: %d \n Got: %d", 2, hits)
+	}
+
+	for i, body := range bodies {
+		expected := "shared a"
+		if i == 0 {
+			expected = "shared b"
+		}
+
+		if body != expected {
+			t.Errorf("Expected: %s \n Got: %s", expected, body)
+		}
+	}
+
+	client.SetRequestCoalescing(false)
+	if client.coalescer != nil {
+		t.Error("Expected coalescing to be disabled")
+	}
+}
+
+func TestClient_SetRequestCoalescing_Ranges(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghij"), 10001)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("ETag", `"v1"`)
+		http.ServeContent(writer, request, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer testServer.Close()
+
+	dir, err := ioutil.TempDir("", "coalesce")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "artifact.bin")
+
+	client := New()
+	client.SetRequestCoalescing(true)
+
+	// Concurrent segments are range requests, which are sent on their own
+	if err := client.DownloadWithOptions(testServer.URL, path, DownloadOptions{Segments: 4}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if downloaded, _ := ioutil.ReadFile(path); !bytes.Equal(downloaded, content) {
+		t.Errorf("Expected: %d bytes \n Got: %d bytes", len(content), len(downloaded))
+	}
+}
+
+func TestClient_SetRequestCoalescing_NoStore(t *testing.T) {
+	var hits int32
+	release := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+		writer.Write([]byte("fresh"))
+	}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetRequestCoalescing(true)
+	httpClient := client.newHTTPClient()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(release)
+
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			request, _ := http.NewRequest(http.MethodGet, testServer.URL, nil)
+			request.Header.Set("Cache-Control", "no-store")
+
+			resp, err := httpClient.Do(request)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+
+	waitFor(t, func() bool { return atomic.LoadInt32(&hits) == 2 })
+}
@@ -0,0 +1,113 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// ErrUnsupportedEncoding is returned when request bodies should be compressed with an encoding lacking an Encoder
+var ErrUnsupportedEncoding = errors.New("no encoder for the content encoding")
+
+// Encoder returns a writer compressing to w, for a Content-Encoding. Closing it flushes the compressed data
+type Encoder func(w io.Writer) (io.WriteCloser, error)
+
+// defaultEncoders are the Content-Encodings available without registering an Encoder
+var defaultEncoders = map[string]Encoder{
+	"gzip": func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriter(w), nil
+	},
+}
+
+// compressTransport compresses request bodies of at least threshold bytes
+type compressTransport struct {
+	next      http.RoundTripper
+	encoding  string
+	encoder   Encoder
+	threshold int
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *compressTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// Bodies the caller already encoded are sent as they are
+	if request.Header.Get("Content-Encoding") != "" {
+		return t.next.RoundTrip(request)
+	}
+
+	body, request, err := requestBodyBytes(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body) == 0 || len(body) < t.threshold {
+		return t.next.RoundTrip(request)
+	}
+
+	if t.encoder == nil {
+		return nil, ErrUnsupportedEncoding
+	}
+
+	var compressed bytes.Buffer
+	writer, err := t.encoder(&compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(body); err != nil {
+		writer.Close()
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	// The compressed body can be read again, so the base transport can replay it on a new connection
+	data := compressed.Bytes()
+	request = request.Clone(request.Context())
+	request.Body = ioutil.NopCloser(bytes.NewReader(data))
+	request.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+	request.ContentLength = int64(len(data))
+	request.Header.Set("Content-Encoding", t.encoding)
+	request.Header.Del("Content-Length")
+
+	return t.next.RoundTrip(request)
+}
+
+// SetCompression compresses request bodies of at least threshold bytes with encoding, setting Content-Encoding. gzip
+// is supported out of the box, other encodings like zstd with SetEncoder. Bodies are compressed again for every
+// attempt, so retries send the same content. Bodies with a Content-Encoding set by the caller are left alone. An empty
+// encoding stops the compression
+func (c *Client) SetCompression(encoding string, threshold int) {
+	c.compression = strings.ToLower(encoding)
+	c.compressionThreshold = threshold
+}
+
+// SetEncoder registers the Encoder of a Content-Encoding for SetCompression, for instance
+//
+//	client.SetEncoder("zstd", func(w io.Writer) (io.WriteCloser, error) {
+//		return zstd.NewWriter(w)
+//	})
+func (c *Client) SetEncoder(encoding string, encoder Encoder) {
+	if c.encoders == nil {
+		c.encoders = make(map[string]Encoder)
+	}
+
+	c.encoders[strings.ToLower(encoding)] = encoder
+}
+
+// encoder returns the Encoder of encoding, nil if it is not supported
+func (c *Client) encoder(encoding string) Encoder {
+	if encoder, ok := c.encoders[encoding]; ok {
+		return encoder
+	}
+
+	return defaultEncoders[encoding]
+}
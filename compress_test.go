@@ -0,0 +1,108 @@
+package requestor
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestClient_SetCompression(t *testing.T) {
+	var mu sync.Mutex
+	var encodings, bodies, digests []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		raw, _ := ioutil.ReadAll(request.Body)
+		sum := sha256.Sum256(raw)
+
+		body := string(raw)
+		if request.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(strings.NewReader(body))
+			if err != nil {
+				writer.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			decompressed, _ := ioutil.ReadAll(reader)
+			body = string(decompressed)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		encodings = append(encodings, request.Header.Get("Content-Encoding"))
+		bodies = append(bodies, body)
+		digests = append(digests, request.Header.Get("Content-Digest")+"|"+base64.StdEncoding.EncodeToString(sum[:]))
+	}))
+	defer testServer.Close()
+
+	// The first attempt fails before reaching the server
+	attempts := 0
+	client := New()
+	client.SetRoundTripper(roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("connection reset")
+		}
+
+		return http.DefaultTransport.RoundTrip(request)
+	}))
+	client.SetMaxRetries(2, 0)
+	client.TimeBetweenRetries = 0
+	client.SetCompression("gzip", 100)
+	client.SetContentDigest(true)
+
+	headers := map[string][]string{"Content-Type": {"application/json"}}
+	large := strings.Repeat("a", 500)
+	for _, data := range []string{large, "small"} {
+		resp, err := client.Post(testServer.URL, headers, nil, map[string]string{"data": data})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected: %d \n Got: %d", http.StatusOK, resp.StatusCode)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(encodings) != 2 || encodings[0] != "gzip" || encodings[1] != "" {
+		t.Errorf("Expected only the large body to be compressed, got %v", encodings)
+		return
+	}
+
+	if bodies[0] != `{"data":"`+large+`"}` || bodies[1] != `{"data":"small"}` {
+		t.Errorf("Expected the original bodies, got %v", bodies)
+	}
+
+	// The digest covers the compressed body
+	for _, digest := range digests {
+		parts := strings.Split(digest, "|")
+		if parts[0] != "sha-256=:"+parts[1]+":" {
+			t.Errorf("Expected: sha-256=:%s: \n Got: %s", parts[1], parts[0])
+		}
+	}
+}
+
+func TestClient_SetCompression_UnsupportedEncoding(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetMaxRetries(1, 0)
+	client.TimeBetweenRetries = 0
+	client.SetCompression("zstd", 0)
+
+	headers := map[string][]string{"Content-Type": {"application/json"}}
+	if _, err := client.Post(testServer.URL, headers, nil, map[string]string{"name": "requestor"}); !errors.Is(err, ErrUnsupportedEncoding) {
+		t.Errorf("Expected: %v \n Got: %v", ErrUnsupportedEncoding, err)
+	}
+}
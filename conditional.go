@@ -0,0 +1,85 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"net/http"
+	"time"
+)
+
+// conditionalKeyPrefix keeps the entries of conditional requests apart from the ones of the response cache when
+// both share a Cache
+const conditionalKeyPrefix = "conditional "
+
+// conditionalTransport remembers the last response of every GET request carrying an ETag or Last-Modified header,
+// and revalidates it on every following request to the same URL instead of downloading it again
+type conditionalTransport struct {
+	next  http.RoundTripper
+	store *responseCache
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *conditionalTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Method != http.MethodGet || request.Header.Get("Range") != "" ||
+		parseCacheControl(request.Header).has("no-store") {
+		return t.next.RoundTrip(request)
+	}
+
+	state := stateFromContext(request.Context())
+	state.setRevalidated(false)
+
+	key := conditionalKeyPrefix + cacheKey(request)
+	entry := t.store.load(key, request)
+
+	outgoing, conditional := request, false
+	if entry != nil {
+		outgoing, conditional = withValidators(request, entry)
+	}
+
+	requestTime := time.Now()
+	response, err := t.next.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	if conditional && response.StatusCode == http.StatusNotModified {
+		response.Body.Close()
+		t.store.freshen(key, entry, response, requestTime)
+
+		state.setRevalidated(true)
+		return entry.toResponse(request, time.Now()), nil
+	}
+
+	if response.StatusCode != http.StatusOK || parseCacheControl(response.Header).has("no-store") ||
+		(response.Header.Get("ETag") == "" && response.Header.Get("Last-Modified") == "") {
+		return response, nil
+	}
+
+	return t.store.storeKey(key, request, response, requestTime)
+}
+
+// Revalidated reports whether response is a stored response the server confirmed to be unchanged with a 304 Not
+// Modified answer to a conditional request
+func Revalidated(response *http.Response) bool {
+	if response == nil || response.Request == nil {
+		return false
+	}
+
+	state := stateFromContext(response.Request.Context())
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return state.revalidated
+}
+
+// SetConditionalRequests makes the Client remember the ETag and Last-Modified of GET responses per URL in store and
+// send them back as If-None-Match and If-Modified-Since on the next request to the same URL. When the server answers
+// 304 Not Modified the stored response is returned instead, see Revalidated. Unlike SetCache, responses are always
+// revalidated regardless of their freshness. A nil store disables conditional requests
+func (c *Client) SetConditionalRequests(store Cache) {
+	if store == nil {
+		c.conditional = nil
+		return
+	}
+
+	c.conditional = &responseCache{cache: store}
+}
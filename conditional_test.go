@@ -0,0 +1,101 @@
+package requestor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_SetConditionalRequests_ETag(t *testing.T) {
+	var version int32 = 1
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		etag := `"v1"`
+		if atomic.LoadInt32(&version) == 2 {
+			etag = `"v2"`
+		}
+
+		writer.Header().Set("Cache-Control", "no-cache")
+		writer.Header().Set("ETag", etag)
+		if request.Header.Get("If-None-Match") == etag {
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+		writer.Write([]byte("config " + etag))
+	}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetConditionalRequests(NewMemoryCache(0))
+
+	testCases := []struct {
+		version     int32
+		body        string
+		revalidated bool
+	}{
+		{1, `config "v1"`, false},
+		{1, `config "v1"`, true},
+		{2, `config "v2"`, false},
+		{2, `config "v2"`, true},
+	}
+
+	for _, testCase := range testCases {
+		atomic.StoreInt32(&version, testCase.version)
+
+		resp, err := client.Get(testServer.URL, nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected: %d \n Got: %d", http.StatusOK, resp.StatusCode)
+		}
+
+		if Revalidated(resp) != testCase.revalidated {
+			t.Errorf("Expected: %t \n Got: %t", testCase.revalidated, Revalidated(resp))
+		}
+
+		if body := readBody(t, resp); body != testCase.body {
+			t.Errorf("Expected: %s \n Got: %s", testCase.body, body)
+		}
+	}
+}
+
+func TestClient_SetConditionalRequests_LastModified(t *testing.T) {
+	lastModified := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Last-Modified", lastModified)
+		if request.Header.Get("If-Modified-Since") == lastModified {
+			writer.WriteHeader(http.StatusNotModified)
+			return
+		}
+		writer.Write([]byte("config"))
+	}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetConditionalRequests(NewMemoryCache(0))
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(testServer.URL, nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if Revalidated(resp) != (i == 1) {
+			t.Errorf("Expected: %t \n Got: %t", i == 1, Revalidated(resp))
+		}
+
+		if body := readBody(t, resp); body != "config" {
+			t.Errorf("Expected: %s \n Got: %s", "config", body)
+		}
+	}
+
+	client.SetConditionalRequests(nil)
+	if client.conditional != nil {
+		t.Error("Expected conditional requests to be disabled")
+	}
+}
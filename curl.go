@@ -0,0 +1,174 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// CurlOptions tunes the curl commands rendered by a Client
+type CurlOptions struct {
+	// Mask hides the values of Authorization, Proxy-Authorization, Cookie and Set-Cookie headers and of the proxy
+	// password, along with the headers, query params and JSON fields listed below
+	Mask            bool
+	MaskHeaders     []string
+	MaskQueryParams []string
+	MaskJSONFields  []string
+}
+
+// shellQuote quotes s so a POSIX shell reads it as a single word
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// requestBodyBytes returns the body of request along with the request to send in its place. That is request itself
+// when its body can be read again, otherwise the body is consumed and a copy of request holding it in memory is
+// returned, as round trippers must not modify the request they are given
+func requestBodyBytes(request *http.Request) ([]byte, *http.Request, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return nil, request, nil
+	}
+
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return nil, request, err
+		}
+		defer body.Close()
+
+		data, err := ioutil.ReadAll(body)
+		return data, request, err
+	}
+
+	data, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, request, err
+	}
+
+	request = request.Clone(request.Context())
+	request.Body = ioutil.NopCloser(bytes.NewReader(data))
+	request.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	return data, request, nil
+}
+
+// ToCurl renders request as a curl command sending the same method, URL, headers and body, through the proxy of the
+// Client and with --insecure when the Client skips TLS verification. A body which can not be read again is buffered
+// in request so it can still be sent
+func (c *Client) ToCurl(request *http.Request, options CurlOptions) (string, error) {
+	body, buffered, err := requestBodyBytes(request)
+	if err != nil {
+		return "", err
+	}
+	request.Body, request.GetBody = buffered.Body, buffered.GetBody
+
+	return c.toCurl(request, body, options)
+}
+
+// toCurl renders request, whose body was already read, as a curl command
+func (c *Client) toCurl(request *http.Request, body []byte, options CurlOptions) (string, error) {
+	var masker *requestLogger
+	if options.Mask {
+		masker = newRequestLogger(nil, LogOptions{
+			RedactHeaders:     options.MaskHeaders,
+			RedactQueryParams: options.MaskQueryParams,
+			RedactJSONFields:  options.MaskJSONFields,
+		})
+	}
+
+	command := []string{"curl"}
+	if request.Method == http.MethodHead {
+		command = append(command, "--head")
+	} else {
+		command = append(command, "-X", request.Method)
+	}
+
+	header := request.Header
+	rawURL := request.URL.String()
+	if masker != nil {
+		header = masker.redactHeader(header)
+		rawURL = masker.redactURL(request.URL)
+	}
+	command = append(command, shellQuote(rawURL))
+
+	if request.Host != "" && request.Host != request.URL.Host {
+		command = append(command, "-H", shellQuote("Host: "+request.Host))
+	}
+
+	fields := make([]string, 0, len(header))
+	for field := range header {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		for _, value := range header[field] {
+			command = append(command, "-H", shellQuote(field+": "+value))
+		}
+	}
+
+	if len(body) > 0 {
+		data := string(body)
+		if masker != nil {
+			data = masker.redactBody(body)
+		}
+		command = append(command, "--data-binary", shellQuote(data))
+	}
+
+	if transport := c.configuredTransport(); transport.Proxy != nil {
+		proxyURL, err := transport.Proxy(request)
+		if err != nil {
+			return "", err
+		}
+
+		if proxyURL != nil {
+			rawProxyURL := proxyURL.String()
+			if masker != nil {
+				rawProxyURL = proxyURL.Redacted()
+			}
+			command = append(command, "--proxy", shellQuote(rawProxyURL))
+		}
+	}
+
+	if c.TLSClientConfig != nil && c.TLSClientConfig.InsecureSkipVerify {
+		command = append(command, "--insecure")
+	}
+
+	return strings.Join(command, " "), nil
+}
+
+// curlTransport hands every request sent over the network to a hook as a curl command
+type curlTransport struct {
+	next    http.RoundTripper
+	client  *Client
+	hook    func(command string)
+	options CurlOptions
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *curlTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, request, err := requestBodyBytes(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if command, err := t.client.toCurl(request, body, t.options); err == nil {
+		t.hook(command)
+	}
+
+	return t.next.RoundTrip(request)
+}
+
+// SetCurlHook makes the Client call hook with the curl command equivalent to every request it sends over the network,
+// retries and redirects included, as rendered by ToCurl. A nil hook disables it
+func (c *Client) SetCurlHook(hook func(command string), options CurlOptions) {
+	c.curlHook = hook
+	c.curlOptions = options
+}
@@ -0,0 +1,209 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Decoder returns a reader decompressing r, for a Content-Encoding
+type Decoder func(r io.Reader) (io.ReadCloser, error)
+
+// decodeGzip decodes the gzip Content-Encoding
+func decodeGzip(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// decodeDeflate decodes the deflate Content-Encoding, which is meant to be zlib wrapped deflate but is sent raw by
+// some servers
+func decodeDeflate(r io.Reader) (io.ReadCloser, error) {
+	buffered := bufio.NewReader(r)
+	if header, err := buffered.Peek(2); err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+
+	return flate.NewReader(buffered), nil
+}
+
+// defaultDecoders are the Content-Encodings decoded without registering a Decoder
+var defaultDecoders = map[string]Decoder{
+	"gzip":    decodeGzip,
+	"x-gzip":  decodeGzip,
+	"deflate": decodeDeflate,
+}
+
+// decodedBody decompresses a response body, the decoders are only created on the first read as some of them read
+// a header right away
+type decodedBody struct {
+	body      io.ReadCloser
+	encodings []string
+	decoders  map[string]Decoder
+
+	reader  io.Reader
+	closers []io.Closer
+	err     error
+}
+
+// open chains the decoders, the last encoding applied being the first one removed
+func (b *decodedBody) open() error {
+	b.reader = b.body
+	for i := len(b.encodings) - 1; i >= 0; i-- {
+		decoder, err := b.decoders[b.encodings[i]](b.reader)
+		if err != nil {
+			return err
+		}
+
+		b.closers = append(b.closers, decoder)
+		b.reader = decoder
+	}
+
+	return nil
+}
+
+// Read implements io.Reader
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		b.err = b.open()
+	}
+
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	return b.reader.Read(p)
+}
+
+// Close implements io.Closer
+func (b *decodedBody) Close() error {
+	for i := len(b.closers) - 1; i >= 0; i-- {
+		b.closers[i].Close()
+	}
+
+	return b.body.Close()
+}
+
+// decompressTransport negotiates compressed responses and decompresses them
+type decompressTransport struct {
+	next     http.RoundTripper
+	decoders map[string]Decoder
+}
+
+// decoder returns the Decoder of encoding, nil if it is not supported
+func (t *decompressTransport) decoder(encoding string) Decoder {
+	if decoder, ok := t.decoders[encoding]; ok {
+		return decoder
+	}
+
+	return defaultDecoders[encoding]
+}
+
+// acceptEncoding lists the supported encodings, the registered ones first
+func (t *decompressTransport) acceptEncoding() string {
+	encodings := make([]string, 0, len(t.decoders))
+	for encoding, decoder := range t.decoders {
+		if _, ok := defaultDecoders[encoding]; !ok && decoder != nil {
+			encodings = append(encodings, encoding)
+		}
+	}
+	sort.Strings(encodings)
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if t.decoder(encoding) != nil {
+			encodings = append(encodings, encoding)
+		}
+	}
+
+	return strings.Join(encodings, ", ")
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *decompressTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// Setting Accept-Encoding also stops the base transport from decompressing gzip on its own
+	if request.Header.Get("Accept-Encoding") == "" {
+		request = request.Clone(request.Context())
+		request.Header.Set("Accept-Encoding", t.acceptEncoding())
+	}
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil || request.Method == http.MethodHead || response.Body == nil {
+		return response, err
+	}
+
+	// Ranges of compressed content cannot be decompressed on their own
+	switch response.StatusCode {
+	case http.StatusNoContent, http.StatusNotModified, http.StatusPartialContent:
+		return response, nil
+	}
+
+	var encodings []string
+	for _, value := range response.Header.Values("Content-Encoding") {
+		for _, encoding := range strings.Split(value, ",") {
+			if encoding = strings.ToLower(strings.TrimSpace(encoding)); encoding != "" && encoding != "identity" {
+				encodings = append(encodings, encoding)
+			}
+		}
+	}
+
+	if len(encodings) == 0 {
+		return response, nil
+	}
+
+	decoders := make(map[string]Decoder, len(encodings))
+	for _, encoding := range encodings {
+		if decoders[encoding] = t.decoder(encoding); decoders[encoding] == nil {
+			// Left as is for the caller to deal with
+			return response, nil
+		}
+	}
+
+	response.Body = &decodedBody{body: response.Body, encodings: encodings, decoders: decoders}
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.ContentLength = -1
+	response.Uncompressed = true
+	stateFromContext(request.Context()).setContentEncoding(strings.Join(encodings, ", "))
+
+	return response, nil
+}
+
+// ContentEncoding returns the Content-Encoding a response was sent with before the Client decompressed it, empty when
+// it was not decompressed, as for responses served from the response cache
+func ContentEncoding(response *http.Response) string {
+	if response == nil || response.Request == nil {
+		return ""
+	}
+
+	state := stateFromContext(response.Request.Context())
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	return state.contentEncoding
+}
+
+// SetDecompression makes the Client negotiate compressed responses, unless Accept-Encoding is set on the request, and
+// decompress them transparently, even when the caller set Accept-Encoding. gzip and deflate are supported out of the
+// box, other encodings like br or zstd with SetDecoder
+func (c *Client) SetDecompression(val bool) {
+	c.decompression = val
+}
+
+// SetDecoder registers the Decoder of a Content-Encoding for SetDecompression, for instance
+//
+//	client.SetDecoder("br", func(r io.Reader) (io.ReadCloser, error) {
+//		return ioutil.NopCloser(brotli.NewReader(r)), nil
+//	})
+//
+// A nil decoder removes it, leaving responses with that encoding compressed
+func (c *Client) SetDecoder(encoding string, decoder Decoder) {
+	if c.decoders == nil {
+		c.decoders = make(map[string]Decoder)
+	}
+
+	c.decoders[strings.ToLower(encoding)] = decoder
+}
@@ -0,0 +1,104 @@
+package requestor
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_SetDecompression(t *testing.T) {
+	content := strings.Repeat("hello requestor ", 100)
+
+	var gzipped, zlibbed, deflated bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipped)
+	gzipWriter.Write([]byte(content))
+	gzipWriter.Close()
+	zlibWriter := zlib.NewWriter(&zlibbed)
+	zlibWriter.Write([]byte(content))
+	zlibWriter.Close()
+	flateWriter, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+	flateWriter.Write([]byte(content))
+	flateWriter.Close()
+
+	var acceptEncoding string
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		acceptEncoding = request.Header.Get("Accept-Encoding")
+
+		switch request.URL.Path {
+		case "/gzip":
+			writer.Header().Set("Content-Encoding", "gzip")
+			writer.Write(gzipped.Bytes())
+		case "/zlib":
+			writer.Header().Set("Content-Encoding", "deflate")
+			writer.Write(zlibbed.Bytes())
+		case "/deflate":
+			writer.Header().Set("Content-Encoding", "deflate")
+			writer.Write(deflated.Bytes())
+		case "/b64":
+			// gzip then b64
+			writer.Header().Set("Content-Encoding", "gzip, b64")
+			writer.Write([]byte(base64.StdEncoding.EncodeToString(gzipped.Bytes())))
+		case "/unknown":
+			writer.Header().Set("Content-Encoding", "unknown")
+			writer.Write([]byte("raw"))
+		}
+	}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetDecompression(true)
+	client.SetDecoder("b64", func(r io.Reader) (io.ReadCloser, error) {
+		return ioutil.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+	})
+
+	for path, encoding := range map[string]string{"/gzip": "gzip", "/zlib": "deflate", "/deflate": "deflate", "/b64": "gzip, b64"} {
+		resp, err := client.Get(testServer.URL+path, nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if body := readBody(t, resp); body != content {
+			t.Errorf("Expected: %d bytes \n Got: %q for %s", len(content), body, path)
+		}
+
+		if ContentEncoding(resp) != encoding || resp.Header.Get("Content-Encoding") != "" || resp.ContentLength != -1 {
+			t.Errorf("Expected: %s \n Got: %s, %s, %d", encoding, ContentEncoding(resp), resp.Header.Get("Content-Encoding"),
+				resp.ContentLength)
+		}
+	}
+
+	if acceptEncoding != "b64, gzip, deflate" {
+		t.Errorf("Expected: %s \n Got: %s", "b64, gzip, deflate", acceptEncoding)
+	}
+
+	// Decompressed even when the caller negotiated the encoding
+	resp, err := client.Get(testServer.URL+"/gzip", map[string][]string{"Accept-Encoding": {"gzip"}}, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if body := readBody(t, resp); body != content || acceptEncoding != "gzip" {
+		t.Errorf("Expected: %d bytes with gzip \n Got: %d bytes with %s", len(content), len(body), acceptEncoding)
+	}
+
+	// Unsupported encodings are left to the caller
+	resp, err = client.Get(testServer.URL+"/unknown", nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if body := readBody(t, resp); body != "raw" || resp.Header.Get("Content-Encoding") != "unknown" || ContentEncoding(resp) != "" {
+		t.Errorf("Expected: raw unknown \n Got: %s %s", body, resp.Header.Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,338 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrContentLengthMismatch is returned when a download ends with a different size than announced by the server
+var ErrContentLengthMismatch = errors.New("downloaded size does not match the content length")
+
+// contentRange parses a Content-Range header value of the form "bytes start-end/total" or "bytes */total", missing
+// parts are -1
+func contentRange(value string) (start, total int64) {
+	start, total = -1, -1
+
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "bytes ") {
+		return start, total
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(value, "bytes "), "/", 2)
+	if len(parts) != 2 {
+		return start, total
+	}
+
+	if parsed, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+		total = parsed
+	}
+
+	if bounds := strings.SplitN(parts[0], "-", 2); len(bounds) == 2 {
+		if parsed, err := strconv.ParseInt(bounds[0], 10, 64); err == nil {
+			start = parsed
+		}
+	}
+
+	return start, total
+}
+
+// validator returns the value to send in If-Range to resume a download of response, strong ETags are preferred as
+// weak ones are not allowed in If-Range
+func validator(response *http.Response) string {
+	if etag := response.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+
+	return response.Header.Get("Last-Modified")
+}
+
+// downloadHeaders returns the headers every request of a download starts with. The transport decompresses gzip
+// responses on its own when it negotiates them, which breaks byte offsets, and caches would hold the whole file in
+// memory
+func downloadHeaders() map[string][]string {
+	return map[string][]string{
+		"Accept-Encoding": {"identity"},
+		"Cache-Control":   {"no-store"},
+	}
+}
+
+// download is the state of a download in progress
+type download struct {
+	client    *Client
+	url       string
+	part      *os.File
+	offset    int64
+	total     int64
+	validator string
+	observe   func(response *http.Response)
+}
+
+// metaPath returns the path of the file keeping the validator of the partial file, so a later run can resume it
+func (d *download) metaPath() string {
+	return d.part.Name() + ".meta"
+}
+
+// saveValidator keeps the validator of the partial file next to it, or removes the stale one if there is none
+func (d *download) saveValidator() error {
+	if d.validator == "" {
+		return removeIfExists(d.metaPath())
+	}
+
+	return writeFileAtomic(d.metaPath(), []byte(d.validator))
+}
+
+// removeIfExists removes the file at path, which may not exist
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// attempt requests the rest of the download and appends it to the partial file, it reports whether the download is
+// complete and whether the error, if any, is worth resuming after
+func (d *download) attempt() (done bool, retry bool, err error) {
+	headers := downloadHeaders()
+	if d.offset > 0 {
+		headers["Range"] = []string{"bytes=" + strconv.FormatInt(d.offset, 10) + "-"}
+		if d.validator != "" {
+			headers["If-Range"] = []string{d.validator}
+		}
+	}
+
+	response, err := d.client.makeRequest(d.url, http.MethodGet, headers, nil, nil)
+	if err != nil {
+		return false, false, err
+	}
+	defer response.Body.Close()
+
+	if d.observe != nil && response.StatusCode < http.StatusMultipleChoices {
+		d.observe(response)
+	}
+
+	switch {
+	case response.StatusCode == http.StatusPartialContent && d.offset > 0:
+		start, total := contentRange(response.Header.Get("Content-Range"))
+		if start != d.offset {
+			return false, false, errors.New("server resumed the download at the wrong offset")
+		}
+		d.total = total
+	case response.StatusCode == http.StatusOK:
+		// The server ignored the range or the file changed since the partial download, so it starts over
+		if err := d.restart(); err != nil {
+			return false, false, err
+		}
+		d.total = response.ContentLength
+		d.validator = validator(response)
+		if err := d.saveValidator(); err != nil {
+			return false, false, err
+		}
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable && d.offset > 0:
+		if _, total := contentRange(response.Header.Get("Content-Range")); total == d.offset {
+			d.total = total
+			return true, false, nil
+		}
+
+		if err := d.restart(); err != nil {
+			return false, false, err
+		}
+
+		return d.attempt()
+	default:
+		return false, false, errors.New("unexpected status " + response.Status)
+	}
+
+	written, err := io.Copy(d.part, response.Body)
+	d.offset += written
+	if err != nil {
+		return false, true, err
+	}
+
+	if d.total >= 0 && d.offset != d.total {
+		return false, false, ErrContentLengthMismatch
+	}
+
+	return true, false, nil
+}
+
+// restart empties the partial file
+func (d *download) restart() error {
+	if err := d.part.Truncate(0); err != nil {
+		return err
+	}
+
+	if _, err := d.part.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	d.offset = 0
+	d.validator = ""
+
+	return d.saveValidator()
+}
+
+// DownloadOptions tunes a download
+type DownloadOptions struct {
+	// Segments splits the download into that many byte ranges fetched concurrently, when the server supports Range
+	// requests. 0 or 1 downloads in a single stream
+	Segments int
+	// SHA256 and MD5 are hex encoded checksums the downloaded file must match
+	SHA256 string
+	MD5    string
+	// VerifyHeaders checks the downloaded file against the checksums sent by the server in the Digest, Repr-Digest,
+	// Content-Digest and Content-MD5 headers
+	VerifyHeaders bool
+	// ETagIsMD5 checks the downloaded file against its ETag, for servers like S3 using the MD5 of the content as ETag
+	ETagIsMD5 bool
+}
+
+// Download saves the body of a GET request to rawURL in the file at path, see DownloadWithOptions
+func (c *Client) Download(rawURL, path string) error {
+	return c.DownloadWithOptions(rawURL, path, DownloadOptions{})
+}
+
+// DownloadWithOptions saves the body of a GET request to rawURL in the file at path. The body is written to
+// path.part first, which is renamed to path once the download is complete and its size matches the one announced by
+// the server, so path never holds a partial file. When reading the body fails, the download resumes where it stopped
+// with a Range request, guarded by If-Range so a file changed meanwhile starts over, up to MaxRetriesOnError times in
+// a row without progress and waiting TimeBetweenRetries in between. The validator is kept in path.part.meta, so a
+// path.part file left by an earlier single stream download is resumed with If-Range too, while one without a
+// validator starts over. Segmented downloads always start over. When checksums are expected, the complete file is
+// verified before the rename and an *IntegrityError is returned on mismatch
+func (c *Client) DownloadWithOptions(rawURL, path string, options DownloadOptions) error {
+	expected := make(checksums)
+	for algorithm, sum := range map[string]string{"sha256": options.SHA256, "md5": options.MD5} {
+		if sum == "" {
+			continue
+		}
+
+		decoded, err := hex.DecodeString(strings.TrimSpace(sum))
+		if err != nil || len(decoded) != newHash(algorithm).Size() {
+			return errors.New("invalid " + algorithm + " checksum " + sum)
+		}
+		expected[algorithm] = decoded
+	}
+
+	part, err := os.OpenFile(path+".part", os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	sums := make(checksums)
+	var observe func(response *http.Response)
+	if options.VerifyHeaders || options.ETagIsMD5 {
+		observe = func(response *http.Response) {
+			if options.VerifyHeaders {
+				sums.addDigestHeaders(response, options.ETagIsMD5)
+			} else {
+				sums.addETag(response)
+			}
+		}
+	}
+
+	meta := part.Name() + ".meta"
+	if options.Segments > 1 {
+		// A partial file written by segments has holes, so it must never be resumed as a single stream
+		if err = removeIfExists(meta); err == nil {
+			err = c.downloadSegments(rawURL, part, options.Segments, observe)
+		}
+	}
+
+	if options.Segments <= 1 || err == errRangesUnsupported {
+		err = c.downloadStream(rawURL, part, observe)
+	}
+
+	if err == nil {
+		err = part.Sync()
+	}
+
+	if closeErr := part.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		// Checksums given by the caller take precedence over the ones sent by the server
+		for algorithm, sum := range expected {
+			sums[algorithm] = sum
+		}
+		err = sums.verifyFile(part.Name())
+	}
+
+	if err != nil {
+		// Corrupted content is not worth resuming, and an empty partial file, as left when the server refused the
+		// first request, has nothing to resume
+		var integrityErr *IntegrityError
+		info, statErr := os.Stat(part.Name())
+		if errors.Is(err, ErrContentLengthMismatch) || errors.As(err, &integrityErr) || (statErr == nil && info.Size() == 0) {
+			os.Remove(part.Name())
+			os.Remove(meta)
+		}
+
+		return err
+	}
+
+	if err := os.Rename(part.Name(), path); err != nil {
+		return err
+	}
+
+	return removeIfExists(meta)
+}
+
+// resume runs attempt until it is done, retrying it up to MaxRetriesOnError times in a row without progress
+func (c *Client) resume(attempt func() (done bool, retry bool, err error), progress func() int64) error {
+	failures := 0
+	for {
+		before := progress()
+
+		done, retry, err := attempt()
+		if done {
+			return nil
+		}
+
+		if progress() > before {
+			failures = 0
+		}
+		failures++
+
+		if !retry || failures >= int(c.MaxRetriesOnError) {
+			return err
+		}
+
+		time.Sleep(time.Duration(c.TimeBetweenRetries) * time.Second)
+	}
+}
+
+// downloadStream downloads rawURL to part in a single stream, resuming after the data already in part
+func (c *Client) downloadStream(rawURL string, part *os.File, observe func(response *http.Response)) error {
+	offset, err := part.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	d := &download{client: c, url: rawURL, part: part, offset: offset, total: -1, observe: observe}
+
+	// A partial file can only be resumed safely with the validator it was downloaded with, otherwise a file changed
+	// on the server meanwhile would be spliced onto stale bytes
+	if offset > 0 {
+		saved, err := ioutil.ReadFile(d.metaPath())
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		if d.validator = strings.TrimSpace(string(saved)); d.validator == "" {
+			if err := d.restart(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return c.resume(d.attempt, func() int64 { return d.offset })
+}
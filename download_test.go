@@ -0,0 +1,246 @@
+package requestor
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_Download(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+
+	var mu sync.Mutex
+	var ranges []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, request.Header.Get("Range")+"|"+request.Header.Get("If-Range"))
+		first := len(ranges) == 1
+		mu.Unlock()
+
+		writer.Header().Set("ETag", `"v1"`)
+		if first {
+			// Drop the connection half way through the body
+			writer.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			writer.Write(content[:len(content)/2])
+			writer.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+
+		http.ServeContent(writer, request, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer testServer.Close()
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "artifact.bin")
+
+	client := New()
+	client.SetMaxRetries(3, 0)
+	client.TimeBetweenRetries = 0
+
+	if err := client.Download(testServer.URL, path); err != nil {
+		t.Error(err)
+		return
+	}
+
+	downloaded, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if !bytes.Equal(downloaded, content) {
+		t.Errorf("Expected: %d bytes \n Got: %d bytes", len(content), len(downloaded))
+	}
+
+	if _, err := os.Stat(path + ".part"); !os.IsNotExist(err) {
+		t.Errorf("Expected the partial file to be renamed, got %v", err)
+	}
+
+	if len(ranges) != 2 || !strings.HasPrefix(ranges[1], "bytes=") || !strings.HasSuffix(ranges[1], `-|"v1"`) {
+		t.Errorf("Expected the second request to resume with Range and If-Range, got %v", ranges)
+	}
+}
+
+func TestClient_Download_PartialFile(t *testing.T) {
+	content := []byte("hello world")
+
+	var ranges []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ranges = append(ranges, request.Header.Get("Range")+"|"+request.Header.Get("If-Range"))
+		writer.Header().Set("ETag", `"v1"`)
+		http.ServeContent(writer, request, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer testServer.Close()
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "hello.txt")
+
+	// A partial file with its validator is resumed
+	ioutil.WriteFile(path+".part", content[:6], 0644)
+	ioutil.WriteFile(path+".part.meta", []byte(`"v1"`), 0644)
+
+	if err := New().Download(testServer.URL, path); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if downloaded, _ := ioutil.ReadFile(path); !bytes.Equal(downloaded, content) {
+		t.Errorf("Expected: %s \n Got: %s", content, downloaded)
+	}
+
+	if len(ranges) != 1 || ranges[0] != `bytes=6-|"v1"` {
+		t.Errorf("Expected a single resumed request, got %v", ranges)
+	}
+
+	if _, err := os.Stat(path + ".part.meta"); !os.IsNotExist(err) {
+		t.Errorf("Expected the validator file to be removed, got %v", err)
+	}
+
+	// A partial file without a validator may be stale, so it starts over
+	ranges = nil
+	ioutil.WriteFile(path+".part", []byte("HELLO "), 0644)
+
+	if err := New().Download(testServer.URL, path); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if downloaded, _ := ioutil.ReadFile(path); !bytes.Equal(downloaded, content) {
+		t.Errorf("Expected: %s \n Got: %s", content, downloaded)
+	}
+
+	if len(ranges) != 1 || ranges[0] != "|" {
+		t.Errorf("Expected a single full request, got %v", ranges)
+	}
+
+	// A complete partial file is only renamed
+	ioutil.WriteFile(path+".part", content, 0644)
+	ioutil.WriteFile(path+".part.meta", []byte(`"v1"`), 0644)
+
+	if err := New().Download(testServer.URL, path); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if downloaded, _ := ioutil.ReadFile(path); !bytes.Equal(downloaded, content) {
+		t.Errorf("Expected: %s \n Got: %s", content, downloaded)
+	}
+}
+
+func TestClient_Download_Cache(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Cache-Control", "max-age=3600")
+		writer.Header().Set("ETag", `"v1"`)
+		http.ServeContent(writer, request, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer testServer.Close()
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	cache := NewMemoryCache(10)
+	client := New()
+	client.SetCache(cache)
+	client.SetConditionalRequests(cache)
+
+	for i, segments := range []int{0, 4} {
+		path := filepath.Join(dir, "artifact"+strconv.Itoa(i)+".bin")
+		if err := client.DownloadWithOptions(testServer.URL, path, DownloadOptions{Segments: segments}); err != nil {
+			t.Error(err)
+			return
+		}
+
+		if downloaded, _ := ioutil.ReadFile(path); !bytes.Equal(downloaded, content) {
+			t.Errorf("Expected: %d bytes \n Got: %d bytes", len(content), len(downloaded))
+		}
+	}
+
+	if len(cache.items) != 0 {
+		t.Errorf("Expected: %d \n Got: %d cached entries", 0, len(cache.items))
+	}
+}
+
+func TestClient_Download_ContentLengthMismatch(t *testing.T) {
+	client := New()
+	client.SetRoundTripper(roundTripperFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode:    http.StatusOK,
+			Header:        make(http.Header),
+			Body:          ioutil.NopCloser(strings.NewReader("short")),
+			ContentLength: 100,
+			Request:       request,
+		}, nil
+	}))
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "short.txt")
+
+	if err := client.Download("http://example.com/short", path); !errors.Is(err, ErrContentLengthMismatch) {
+		t.Errorf("Expected: %v \n Got: %v", ErrContentLengthMismatch, err)
+	}
+
+	for _, leftover := range []string{path, path + ".part"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to exist, got %v", leftover, err)
+		}
+	}
+}
+
+func TestClient_Download_NotFound(t *testing.T) {
+	testServer := httptest.NewServer(http.NotFoundHandler())
+	defer testServer.Close()
+
+	dir, err := ioutil.TempDir("", "download")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "missing.txt")
+
+	if err := New().Download(testServer.URL, path); err == nil {
+		t.Error("Expected the download to fail")
+	}
+
+	for _, leftover := range []string{path, path + ".part", path + ".part.meta"} {
+		if _, err := os.Stat(leftover); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to exist, got %v", leftover, err)
+		}
+	}
+}
+
+type roundTripperFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
@@ -0,0 +1,302 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// BalancingStrategy decides which endpoint of an EndpointPool a request is sent to
+type BalancingStrategy int
+
+const (
+	// RoundRobin sends requests to each endpoint in turn
+	RoundRobin BalancingStrategy = iota
+	// LeastInFlight sends requests to the endpoint with the fewest requests in flight
+	LeastInFlight
+	// Weighted picks endpoints randomly in proportion to their weight
+	Weighted
+	// Random picks endpoints uniformly at random
+	Random
+)
+
+const (
+	// DefaultEjectionThreshold is the number of consecutive failures after which an endpoint is ejected
+	DefaultEjectionThreshold = 3
+	// DefaultEjectionTime is how long an ejected endpoint stops receiving requests
+	DefaultEjectionTime = 30 * time.Second
+)
+
+// Endpoint is a single base URL of an EndpointPool. Its settings are fixed once it is added to the pool
+type Endpoint struct {
+	url    *url.URL
+	weight int
+
+	inFlight       int
+	failures       int
+	ejectedUntil   time.Time
+	down           int32
+	probeSuccesses int
+	probeFailures  int
+}
+
+// URL returns the base URL requests are sent to. Its path, if any, is prepended to the path of the request
+func (e *Endpoint) URL() *url.URL {
+	endpointURL := *e.url
+	return &endpointURL
+}
+
+// Weight returns the relative share of requests the endpoint gets with the Weighted strategy
+func (e *Endpoint) Weight() int {
+	return e.weight
+}
+
+// resolve returns requestURL with its scheme and host replaced by the ones of the endpoint
+func (e *Endpoint) resolve(requestURL *url.URL) *url.URL {
+	resolved := *requestURL
+	resolved.Scheme = e.url.Scheme
+	resolved.Host = e.url.Host
+	resolved.User = e.url.User
+
+	if basePath := strings.TrimSuffix(e.url.Path, "/"); basePath != "" {
+		resolved.Path = basePath + "/" + strings.TrimPrefix(requestURL.Path, "/")
+		resolved.RawPath = ""
+	}
+
+	return &resolved
+}
+
+// EndpointPool spreads requests over several base URLs of the same service. Endpoints failing repeatedly are
+// ejected for a while, and retries of a failed request go to another endpoint
+type EndpointPool struct {
+	mu                sync.Mutex
+	endpoints         []*Endpoint
+	strategy          BalancingStrategy
+	next              int
+	ejectionThreshold int
+	ejectionTime      time.Duration
+	random            *rand.Rand
+	healthCheck       *healthCheck
+}
+
+// NewEndpointPool creates an empty EndpointPool which uses strategy to pick endpoints
+func NewEndpointPool(strategy BalancingStrategy) *EndpointPool {
+	return &EndpointPool{
+		strategy:          strategy,
+		ejectionThreshold: DefaultEjectionThreshold,
+		ejectionTime:      DefaultEjectionTime,
+		random:            rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// AddEndpoint adds a base URL of the form scheme://host[:port][/path] to the pool. weight only matters for the
+// Weighted strategy, a weight smaller than 1 is treated as 1
+func (p *EndpointPool) AddEndpoint(rawURL string, weight int) error {
+	endpointURL, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if endpointURL.Scheme == "" || endpointURL.Host == "" {
+		return errors.New("endpoint should be of the form scheme://host[:port][/path]")
+	}
+
+	if weight < 1 {
+		weight = 1
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.endpoints = append(p.endpoints, &Endpoint{url: endpointURL, weight: weight})
+
+	return nil
+}
+
+// SetEjection sets after how many consecutive failures an endpoint is ejected and for how long. A failure is a
+// transport error or a 5xx response. A threshold of 0 disables ejection
+func (p *EndpointPool) SetEjection(threshold int, ejectionTime time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.ejectionThreshold = threshold
+	p.ejectionTime = ejectionTime
+}
+
+// Endpoints returns the endpoints of the pool
+func (p *EndpointPool) Endpoints() []*Endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	endpoints := make([]*Endpoint, len(p.endpoints))
+	copy(endpoints, p.endpoints)
+
+	return endpoints
+}
+
+// available reports whether endpoint may receive requests, the caller must hold the lock
+func (p *EndpointPool) available(endpoint *Endpoint, now time.Time) bool {
+	return atomic.LoadInt32(&endpoint.down) == 0 && !now.Before(endpoint.ejectedUntil)
+}
+
+// pick selects the endpoint for the next request and counts it as in flight. Endpoints in exclude, which already
+// failed for the same call, and unavailable endpoints are skipped unless no other endpoint is left
+func (p *EndpointPool) pick(exclude map[*Endpoint]bool) *Endpoint {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.endpoints) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var candidates []*Endpoint
+
+	for _, endpoint := range p.endpoints {
+		if !exclude[endpoint] && p.available(endpoint, now) {
+			candidates = append(candidates, endpoint)
+		}
+	}
+
+	if len(candidates) == 0 {
+		for _, endpoint := range p.endpoints {
+			if p.available(endpoint, now) {
+				candidates = append(candidates, endpoint)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		candidates = p.endpoints
+	}
+
+	var endpoint *Endpoint
+
+	switch p.strategy {
+	case LeastInFlight:
+		endpoint = candidates[0]
+		for _, candidate := range candidates[1:] {
+			if candidate.inFlight < endpoint.inFlight {
+				endpoint = candidate
+			}
+		}
+	case Weighted:
+		total := 0
+		for _, candidate := range candidates {
+			total += candidate.weight
+		}
+
+		n := p.random.Intn(total)
+		for _, candidate := range candidates {
+			if n < candidate.weight {
+				endpoint = candidate
+				break
+			}
+			n -= candidate.weight
+		}
+	case Random:
+		endpoint = candidates[p.random.Intn(len(candidates))]
+	default:
+		endpoint = candidates[p.next%len(candidates)]
+		p.next++
+	}
+
+	endpoint.inFlight++
+
+	return endpoint
+}
+
+// done marks a request to endpoint as no longer in flight
+func (p *EndpointPool) done(endpoint *Endpoint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	endpoint.inFlight--
+}
+
+// report records the outcome of a request to endpoint, ejecting it once it failed too many times in a row
+func (p *EndpointPool) report(endpoint *Endpoint, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !failed {
+		endpoint.failures = 0
+		return
+	}
+
+	endpoint.failures++
+	if p.ejectionThreshold > 0 && endpoint.failures >= p.ejectionThreshold {
+		endpoint.ejectedUntil = time.Now().Add(p.ejectionTime)
+		endpoint.failures = 0
+	}
+}
+
+// endpointTransport sends each request to an endpoint of the pool instead of the host of its URL
+type endpointTransport struct {
+	next http.RoundTripper
+	pool *EndpointPool
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *endpointTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	state := stateFromContext(request.Context())
+
+	state.mu.Lock()
+	// Redirects relative to the original URL are sent to the pool too, redirects to other hosts are left alone
+	if request.Response == nil {
+		state.poolHost = request.URL.Host
+	} else if request.URL.Host != state.poolHost {
+		state.mu.Unlock()
+		return t.next.RoundTrip(request)
+	}
+	endpoint := t.pool.pick(state.failedEndpoints)
+	state.mu.Unlock()
+
+	if endpoint == nil {
+		return t.next.RoundTrip(request)
+	}
+
+	target := request.Clone(request.Context())
+	target.URL = endpoint.resolve(request.URL)
+	target.Host = ""
+
+	response, err := t.next.RoundTrip(target)
+
+	failed := err != nil || response.StatusCode >= http.StatusInternalServerError
+	t.pool.report(endpoint, failed)
+
+	if failed {
+		state.mu.Lock()
+		if state.failedEndpoints == nil {
+			state.failedEndpoints = make(map[*Endpoint]bool)
+		}
+		state.failedEndpoints[endpoint] = true
+		state.mu.Unlock()
+	}
+
+	if err != nil {
+		t.pool.done(endpoint)
+		return nil, err
+	}
+
+	response.Body = newCallbackBody(response.Body, func() { t.pool.done(endpoint) })
+
+	return response, nil
+}
+
+// SetEndpointPool makes the Client send requests to the endpoints of pool. The scheme and host of request URLs are
+// replaced by the ones of the picked endpoint, so URLs can be given as a path like "/v1/users". Retries go to a
+// different endpoint than the ones which already failed. As only transport errors are retried, a 5xx response is
+// returned to the caller rather than failed over, though it counts towards ejecting its endpoint. A nil pool restores
+// the default behaviour. When the pool has a health check configured it starts running in the background until Close
+// is called
+func (c *Client) SetEndpointPool(pool *EndpointPool) {
+	c.endpoints = pool
+	c.startHealthChecks()
+}
@@ -0,0 +1,142 @@
+package requestor
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newNamedTestServer(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(name + request.URL.Path))
+	}))
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+	}
+
+	return string(body)
+}
+
+func TestClient_SetEndpointPool_RoundRobin(t *testing.T) {
+	first := newNamedTestServer("first")
+	defer first.Close()
+	second := newNamedTestServer("second")
+	defer second.Close()
+
+	pool := NewEndpointPool(RoundRobin)
+	if err := pool.AddEndpoint(first.URL, 1); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := pool.AddEndpoint(second.URL+"/base", 1); err != nil {
+		t.Error(err)
+		return
+	}
+
+	client := New()
+	client.SetEndpointPool(pool)
+
+	expected := []string{"first/users", "second/base/users", "first/users"}
+	for _, want := range expected {
+		resp, err := client.Get("/users", nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if got := readBody(t, resp); got != want {
+			t.Errorf("Expected: %s \n Got: %s", want, got)
+		}
+	}
+}
+
+func TestClient_SetEndpointPool_Failover(t *testing.T) {
+	dead := newNamedTestServer("dead")
+	dead.Close()
+	alive := newNamedTestServer("alive")
+	defer alive.Close()
+
+	pool := NewEndpointPool(RoundRobin)
+	pool.SetEjection(1, time.Minute)
+	pool.AddEndpoint(dead.URL, 1)
+	pool.AddEndpoint(alive.URL, 1)
+
+	client := New()
+	client.SetMaxRetries(2, 0)
+	client.TimeBetweenRetries = 0
+	client.SetEndpointPool(pool)
+
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get("http://service/ping", nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if got := readBody(t, resp); got != "alive/ping" {
+			t.Errorf("Expected: %s \n Got: %s", "alive/ping", got)
+		}
+	}
+
+	if endpoint := pool.Endpoints()[0]; endpoint.ejectedUntil.IsZero() {
+		t.Error("Expected failing endpoint to be ejected")
+	}
+}
+
+func TestEndpointPool_Strategies(t *testing.T) {
+	pool := NewEndpointPool(LeastInFlight)
+	pool.AddEndpoint("http://a", 1)
+	pool.AddEndpoint("http://b", 1)
+
+	first := pool.pick(nil)
+	second := pool.pick(nil)
+	if first == second {
+		t.Error("Expected least in flight to spread requests")
+	}
+
+	pool.done(first)
+	if third := pool.pick(nil); third != first {
+		t.Errorf("Expected: %s \n Got: %s", first.URL(), third.URL())
+	}
+
+	weighted := NewEndpointPool(Weighted)
+	weighted.AddEndpoint("http://a", 1)
+	weighted.AddEndpoint("http://b", 0)
+	weighted.AddEndpoint("http://c", 98)
+
+	counts := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		counts[weighted.pick(nil).URL().Host]++
+	}
+
+	if counts["c"] < 900 {
+		t.Errorf("Expected heavy endpoint to get most requests, got %v", counts)
+	}
+
+	if weight := weighted.Endpoints()[1].Weight(); weight != 1 {
+		t.Errorf("Expected: %d \n Got: %d", 1, weight)
+	}
+
+	random := NewEndpointPool(Random)
+	random.AddEndpoint("http://a", 1)
+	random.AddEndpoint("http://b", 1)
+
+	exclude := map[*Endpoint]bool{random.Endpoints()[0]: true}
+	for i := 0; i < 10; i++ {
+		if endpoint := random.pick(exclude); endpoint.URL().Host != "b" {
+			t.Errorf("Expected: %s \n Got: %s", "b", endpoint.URL().Host)
+		}
+	}
+
+	if err := random.AddEndpoint("not a url", 1); err == nil {
+		t.Error("invalid endpoint gave no error")
+	}
+}
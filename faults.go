@@ -0,0 +1,193 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrInjectedFault is the error of requests failed by fault injection
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultType is the kind of failure a Fault injects
+type FaultType int
+
+const (
+	// FaultLatency delays the request by Latency before sending it
+	FaultLatency FaultType = iota
+	// FaultDrop fails the request with ErrInjectedFault without sending it, as if the connection was dropped
+	FaultDrop
+	// FaultStatus answers the request with StatusCode without sending it
+	FaultStatus
+	// FaultTruncate ends the response body cleanly after Bytes bytes
+	FaultTruncate
+	// FaultReset fails reading the response body with ErrInjectedFault after Bytes bytes, as if the connection was
+	// reset mid-stream
+	FaultReset
+)
+
+// Fault is a failure injected into the requests of a Client for chaos testing
+type Fault struct {
+	Type FaultType
+	// Probability is the chance, between 0 and 1, that a matching request gets the fault. 0 means never and 1 always
+	Probability float64
+	// Host restricts the fault to a hostname, empty means any host
+	Host string
+	// Path restricts the fault to request paths matching a path.Match pattern, empty means any path
+	Path string
+	// Latency is the delay added by FaultLatency
+	Latency time.Duration
+	// StatusCode is the status answered by FaultStatus
+	StatusCode int
+	// Bytes is how many bytes of the response body FaultTruncate and FaultReset let through
+	Bytes int64
+}
+
+// matches tells whether the fault applies to request
+func (f Fault) matches(request *http.Request) bool {
+	if f.Host != "" && f.Host != request.URL.Hostname() {
+		return false
+	}
+
+	if f.Path != "" {
+		if matched, err := path.Match(f.Path, request.URL.Path); err != nil || !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// faultInjector picks the faults applied to each request
+type faultInjector struct {
+	faults []Fault
+
+	mu     sync.Mutex
+	random *rand.Rand
+}
+
+// pick returns the faults applying to request, each drawn with its probability
+func (i *faultInjector) pick(request *http.Request) []Fault {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var faults []Fault
+	for _, fault := range i.faults {
+		if !fault.matches(request) {
+			continue
+		}
+
+		if fault.Probability < 1 && i.random.Float64() >= fault.Probability {
+			continue
+		}
+
+		faults = append(faults, fault)
+	}
+
+	return faults
+}
+
+// faultyBody lets through the first bytes of a body then ends it with err
+type faultyBody struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+}
+
+// Read implements io.Reader
+func (b *faultyBody) Read(p []byte) (n int, err error) {
+	if b.remaining <= 0 {
+		return 0, b.err
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+
+	n, err = b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+
+	return n, err
+}
+
+// faultTransport injects faults into requests and their responses
+type faultTransport struct {
+	next     http.RoundTripper
+	injector *faultInjector
+}
+
+// closeBody closes the body of a request which is not sent, as a RoundTripper must
+func closeBody(request *http.Request) {
+	if request.Body != nil {
+		request.Body.Close()
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *faultTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	faults := t.injector.pick(request)
+
+	for _, fault := range faults {
+		switch fault.Type {
+		case FaultLatency:
+			timer := time.NewTimer(fault.Latency)
+			select {
+			case <-timer.C:
+			case <-request.Context().Done():
+				timer.Stop()
+				closeBody(request)
+				return nil, request.Context().Err()
+			}
+		case FaultDrop:
+			closeBody(request)
+			return nil, ErrInjectedFault
+		case FaultStatus:
+			closeBody(request)
+			return &http.Response{
+				Status:     strconv.Itoa(fault.StatusCode) + " " + http.StatusText(fault.StatusCode),
+				StatusCode: fault.StatusCode,
+				Proto:      "HTTP/1.1",
+				ProtoMajor: 1,
+				ProtoMinor: 1,
+				Header:     make(http.Header),
+				Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+				Request:    request,
+			}, nil
+		}
+	}
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fault := range faults {
+		switch fault.Type {
+		case FaultTruncate:
+			response.Body = &faultyBody{ReadCloser: response.Body, remaining: fault.Bytes, err: io.EOF}
+		case FaultReset:
+			response.Body = &faultyBody{ReadCloser: response.Body, remaining: fault.Bytes, err: ErrInjectedFault}
+		}
+	}
+
+	return response, nil
+}
+
+// SetFaults makes the Client inject faults into its requests, to check how retries and timeouts cope with a failing
+// network. Every matching fault is drawn independently, in order. Calling it without faults disables injection
+func (c *Client) SetFaults(faults ...Fault) {
+	if len(faults) == 0 {
+		c.faults = nil
+		return
+	}
+
+	c.faults = &faultInjector{faults: faults, random: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
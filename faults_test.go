@@ -0,0 +1,133 @@
+package requestor
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_SetFaults(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("0123456789"))
+	}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetTimeout(time.Second)
+	client.SetFaults(
+		Fault{Type: FaultDrop, Probability: 1, Path: "/drop"},
+		Fault{Type: FaultStatus, Probability: 1, Path: "/status", StatusCode: http.StatusServiceUnavailable},
+		Fault{Type: FaultTruncate, Probability: 1, Path: "/truncate", Bytes: 4},
+		Fault{Type: FaultReset, Probability: 1, Path: "/reset", Bytes: 6},
+		Fault{Type: FaultLatency, Probability: 1, Path: "/slow", Latency: 50 * time.Millisecond},
+		Fault{Type: FaultDrop, Probability: 1, Host: "other.example.com"},
+	)
+
+	if _, err := client.Get(testServer.URL+"/drop", nil, nil); !errors.Is(err, ErrInjectedFault) {
+		t.Errorf("Expected: %v \n Got: %v", ErrInjectedFault, err)
+	}
+
+	resp, err := client.Get(testServer.URL+"/status", nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	readBody(t, resp)
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected: %d \n Got: %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+
+	resp, err = client.Get(testServer.URL+"/truncate", nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if body := readBody(t, resp); body != "0123" {
+		t.Errorf("Expected: %s \n Got: %s", "0123", body)
+	}
+
+	resp, err = client.Get(testServer.URL+"/reset", nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !errors.Is(err, ErrInjectedFault) || string(body) != "012345" {
+		t.Errorf("Expected the body to fail after 6 bytes, got %q and %v", body, err)
+	}
+
+	start := time.Now()
+	resp, err = client.Get(testServer.URL+"/slow", nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if body := readBody(t, resp); body != "0123456789" || time.Since(start) < 50*time.Millisecond {
+		t.Errorf("Expected the full body after the injected latency, got %s after %v", body, time.Since(start))
+	}
+
+	client.SetTimeout(10 * time.Millisecond)
+	if _, err := client.Get(testServer.URL+"/slow", nil, nil); err == nil || errors.Is(err, ErrInjectedFault) {
+		t.Errorf("Expected the injected latency to hit the timeout, got %v", err)
+	}
+}
+
+func TestFaultInjector_Probability(t *testing.T) {
+	injector := &faultInjector{
+		faults: []Fault{{Type: FaultDrop, Probability: 0.25}},
+		random: rand.New(rand.NewSource(1)),
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "http://example.com/", strings.NewReader(""))
+
+	picked := 0
+	for i := 0; i < 1000; i++ {
+		picked += len(injector.pick(request))
+	}
+
+	if picked < 200 || picked > 300 {
+		t.Errorf("Expected about 250 faults out of 1000 requests, got %d", picked)
+	}
+
+	injector.faults[0].Probability = 0
+	if faults := injector.pick(request); len(faults) != 0 {
+		t.Errorf("Expected: %d \n Got: %d faults", 0, len(faults))
+	}
+}
+
+type closeTrackingBody struct {
+	*strings.Reader
+	closed bool
+}
+
+func (b *closeTrackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestFaultTransport_ClosesBody(t *testing.T) {
+	for _, fault := range []Fault{{Type: FaultDrop, Probability: 1}, {Type: FaultStatus, Probability: 1, StatusCode: 503}} {
+		transport := &faultTransport{
+			next:     http.DefaultTransport,
+			injector: &faultInjector{faults: []Fault{fault}, random: rand.New(rand.NewSource(1))},
+		}
+
+		body := &closeTrackingBody{Reader: strings.NewReader("payload")}
+		request := httptest.NewRequest(http.MethodPost, "http://example.com/", body)
+		transport.RoundTrip(request)
+
+		if !body.closed {
+			t.Errorf("Expected the request body to be closed by fault %d", fault.Type)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR is an HTTP Archive 1.2 document
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of an HTTP Archive
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator names the application which created an HTTP Archive
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a single request sent over the network along with its response. Attempt counts the retries of a call,
+// starting at 1, and Error holds the reason of requests which got no response
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Attempt         int         `json:"_attempt"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+// HARNameValue is a header or query param of an HTTP Archive
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARRequest is a request of an HTTP Archive
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARPostData is the body of a request of an HTTP Archive
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARResponse is a response of an HTTP Archive, Status is 0 for requests which got no response
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+// HARContent is the body of a response of an HTTP Archive, binary bodies are base64 encoded
+type HARContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARTimings is the breakdown of the time spent on a request of an HTTP Archive in milliseconds, -1 means it does
+// not apply. Connect includes SSL
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// HARRecorder records the traffic of a Client as an HTTP Archive. Bodies are kept whole, so it is meant for
+// debugging sessions rather than long running processes
+type HARRecorder struct {
+	mu      sync.Mutex
+	entries []HAREntry
+}
+
+// NewHARRecorder creates an empty HARRecorder
+func NewHARRecorder() *HARRecorder {
+	return &HARRecorder{}
+}
+
+// HAR returns the traffic recorded so far, sorted by start time
+func (r *HARRecorder) HAR() HAR {
+	r.mu.Lock()
+	entries := make([]HAREntry, len(r.entries))
+	copy(entries, r.entries)
+	r.mu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+
+	return HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "Requestor", Version: "1.0"},
+		Entries: entries,
+	}}
+}
+
+// WriteTo writes the traffic recorded so far to w as a HAR file
+func (r *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(r.HAR(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+
+	return int64(n), err
+}
+
+// Reset drops the traffic recorded so far
+func (r *HARRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = nil
+}
+
+// record adds entry to the recorded traffic
+func (r *HARRecorder) record(entry HAREntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, entry)
+}
+
+// harHeaders converts header to HAR name value pairs, sorted by name
+func harHeaders(header http.Header) []HARNameValue {
+	pairs := make([]HARNameValue, 0, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			pairs = append(pairs, HARNameValue{Name: name, Value: value})
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+
+	return pairs
+}
+
+// harCookies converts cookies to HAR name value pairs
+func harCookies(cookies []*http.Cookie) []HARNameValue {
+	pairs := make([]HARNameValue, 0, len(cookies))
+	for _, cookie := range cookies {
+		pairs = append(pairs, HARNameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+
+	return pairs
+}
+
+// harRequest converts request, whose body was already read, to its HAR form
+func harRequest(request *http.Request, body []byte) HARRequest {
+	harRequest := HARRequest{
+		Method:      request.Method,
+		URL:         request.URL.String(),
+		HTTPVersion: request.Proto,
+		Cookies:     harCookies(request.Cookies()),
+		Headers:     harHeaders(request.Header),
+		QueryString: make([]HARNameValue, 0),
+		HeadersSize: -1,
+	}
+
+	if harRequest.HTTPVersion == "" {
+		harRequest.HTTPVersion = "HTTP/1.1"
+	}
+
+	query := request.URL.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range query[name] {
+			harRequest.QueryString = append(harRequest.QueryString, HARNameValue{Name: name, Value: value})
+		}
+	}
+
+	if body != nil {
+		harRequest.BodySize = int64(len(body))
+		harRequest.PostData = &HARPostData{MimeType: request.Header.Get("Content-Type"), Text: string(body)}
+	}
+
+	return harRequest
+}
+
+// harContent converts a response body to its HAR form, base64 encoding binary bodies
+func harContent(header http.Header, body []byte) HARContent {
+	content := HARContent{Size: int64(len(body)), MimeType: header.Get("Content-Type")}
+
+	mediaType, _, _ := mime.ParseMediaType(content.MimeType)
+	if utf8.Valid(body) && (mediaType == "" || strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") || strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/javascript" || mediaType == "application/x-www-form-urlencoded") {
+		content.Text = string(body)
+	} else if len(body) > 0 {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+
+	return content
+}
+
+// milliseconds converts d to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// harTimings converts timing to its HAR form
+func harTimings(timing Timing) HARTimings {
+	timings := HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Receive: milliseconds(timing.Transfer)}
+
+	wait := timing.TimeToFirstByte
+	if !timing.ConnectionReused {
+		timings.DNS = milliseconds(timing.DNS)
+		timings.Connect = milliseconds(timing.Connect + timing.TLS)
+		wait -= timing.DNS + timing.Connect + timing.TLS
+		if timing.TLS > 0 {
+			timings.SSL = milliseconds(timing.TLS)
+		}
+	}
+
+	if wait > 0 {
+		timings.Wait = milliseconds(wait)
+	}
+
+	return timings
+}
+
+// harTransport records every request sent over the network along with its response
+type harTransport struct {
+	next     http.RoundTripper
+	recorder *HARRecorder
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *harTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, request, err := requestBodyBytes(request)
+	if err != nil {
+		return nil, err
+	}
+
+	timing := &timingRecorder{start: time.Now()}
+	entry := HAREntry{
+		StartedDateTime: timing.start,
+		Request:         harRequest(request, body),
+		Attempt:         stateFromContext(request.Context()).currentAttempt(),
+	}
+
+	response, err := t.next.RoundTrip(request.WithContext(httptrace.WithClientTrace(request.Context(), timing.trace())))
+	if err != nil {
+		timing.done()
+		entry.Time = milliseconds(timing.get().Total)
+		entry.Timings = harTimings(timing.get())
+		entry.Response = HARResponse{
+			HTTPVersion: entry.Request.HTTPVersion,
+			Cookies:     make([]HARNameValue, 0),
+			Headers:     make([]HARNameValue, 0),
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+		entry.Error = err.Error()
+
+		t.recorder.record(entry)
+		return nil, err
+	}
+
+	entry.Response = HARResponse{
+		Status:      response.StatusCode,
+		StatusText:  http.StatusText(response.StatusCode),
+		HTTPVersion: response.Proto,
+		Cookies:     harCookies(response.Cookies()),
+		Headers:     harHeaders(response.Header),
+		RedirectURL: response.Header.Get("Location"),
+		HeadersSize: -1,
+	}
+	if status := strings.SplitN(response.Status, " ", 2); len(status) == 2 {
+		entry.Response.StatusText = status[1]
+	}
+
+	finish := func(body []byte) {
+		timing.done()
+		entry.Time = milliseconds(timing.get().Total)
+		entry.Timings = harTimings(timing.get())
+		entry.Response.Content = harContent(response.Header, body)
+		entry.Response.BodySize = int64(len(body))
+
+		t.recorder.record(entry)
+	}
+
+	if response.Body == nil {
+		finish(nil)
+		return response, nil
+	}
+
+	response.Body = &loggedBody{
+		ReadCloser: response.Body,
+		limit:      int(^uint(0) >> 1),
+		done: func(body *loggedBody) {
+			finish(body.captured.Bytes())
+		},
+	}
+
+	return response, nil
+}
+
+// SetHARRecorder makes the Client record every request it sends over the network, retries and redirects included,
+// along with its response and timings into recorder. A nil recorder disables recording
+func (c *Client) SetHARRecorder(recorder *HARRecorder) {
+	c.harRecorder = recorder
+}
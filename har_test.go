@@ -0,0 +1,113 @@
+package requestor
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClient_SetHARRecorder(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/image" {
+			writer.Header().Set("Content-Type", "image/png")
+			writer.Write([]byte{0x89, 'P', 'N', 'G', 0xff})
+			return
+		}
+
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(http.StatusCreated)
+		writer.Write([]byte(`{"id":1}`))
+	}))
+	defer testServer.Close()
+
+	recorder := NewHARRecorder()
+	client := New()
+	client.SetHARRecorder(recorder)
+
+	resp, err := client.Post(testServer.URL+"/users", map[string][]string{"Content-Type": {"application/json"}}, map[string][]string{"page": {"2"}}, map[string]string{"name": "gopher"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	readBody(t, resp)
+
+	resp, err = client.Get(testServer.URL+"/image", nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	readBody(t, resp)
+
+	entries := recorder.HAR().Log.Entries
+	if len(entries) != 2 {
+		t.Errorf("Expected: %d \n Got: %d", 2, len(entries))
+		return
+	}
+
+	post := entries[0]
+	if post.Request.Method != http.MethodPost || post.Request.PostData == nil || post.Request.PostData.Text != `{"name":"gopher"}` {
+		t.Errorf("Expected the POST body to be recorded, got %+v", post.Request)
+	}
+
+	if len(post.Request.QueryString) != 1 || post.Request.QueryString[0] != (HARNameValue{Name: "page", Value: "2"}) {
+		t.Errorf("Expected the query string to be recorded, got %+v", post.Request.QueryString)
+	}
+
+	if post.Response.Status != http.StatusCreated || post.Response.StatusText != "Created" || post.Response.Content.Text != `{"id":1}` {
+		t.Errorf("Expected the response to be recorded, got %+v", post.Response)
+	}
+
+	if post.Attempt != 1 || post.Time <= 0 || post.Timings.Wait <= 0 {
+		t.Errorf("Expected the attempt and timings to be recorded, got %+v", post)
+	}
+
+	image := entries[1].Response.Content
+	if image.Encoding != "base64" || image.Text != "iVBOR/8=" || image.Size != 5 {
+		t.Errorf("Expected the binary body to be base64 encoded, got %+v", image)
+	}
+
+	var buffer bytes.Buffer
+	if _, err := recorder.WriteTo(&buffer); err != nil {
+		t.Error(err)
+		return
+	}
+
+	var har map[string]map[string]interface{}
+	if err := json.Unmarshal(buffer.Bytes(), &har); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if har["log"]["version"] != "1.2" || len(har["log"]["entries"].([]interface{})) != 2 {
+		t.Errorf("Expected a HAR 1.2 document with 2 entries, got %s", buffer.String())
+	}
+}
+
+func TestClient_SetHARRecorder_Retries(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	testServer.Close()
+
+	recorder := NewHARRecorder()
+	client := New()
+	client.SetMaxRetries(2, 0)
+	client.TimeBetweenRetries = 0
+	client.SetHARRecorder(recorder)
+
+	if _, err := client.Get(testServer.URL, nil, nil); err == nil {
+		t.Error("Expected request to a closed server to fail")
+	}
+
+	entries := recorder.HAR().Log.Entries
+	if len(entries) != 2 {
+		t.Errorf("Expected: %d \n Got: %d", 2, len(entries))
+		return
+	}
+
+	for i, entry := range entries {
+		if entry.Attempt != i+1 || entry.Response.Status != 0 || entry.Error == "" {
+			t.Errorf("Expected failed attempt %d to be recorded with its error, got %+v", i+1, entry)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// healthCheck holds the active health check settings of an EndpointPool
+type healthCheck struct {
+	path               string
+	interval           time.Duration
+	healthyThreshold   int
+	unhealthyThreshold int
+}
+
+// healthChecker probes every endpoint of a pool in the background until stopped
+type healthChecker struct {
+	pool   *EndpointPool
+	client *http.Client
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// SetHealthCheck makes the pool actively probe path on every endpoint each interval once it is attached to a Client.
+// An endpoint is marked down after unhealthyThreshold failed probes in a row and up again after healthyThreshold
+// successful ones. A probe succeeds when the endpoint answers with a 2xx or 3xx status. Endpoints marked down get no
+// requests. Thresholds smaller than 1 are treated as 1
+func (p *EndpointPool) SetHealthCheck(path string, interval time.Duration, healthyThreshold, unhealthyThreshold int) {
+	if healthyThreshold < 1 {
+		healthyThreshold = 1
+	}
+
+	if unhealthyThreshold < 1 {
+		unhealthyThreshold = 1
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.healthCheck = &healthCheck{
+		path:               path,
+		interval:           interval,
+		healthyThreshold:   healthyThreshold,
+		unhealthyThreshold: unhealthyThreshold,
+	}
+}
+
+// Healthy reports whether the last active health checks found the endpoint up. Endpoints are healthy until a health
+// check says otherwise
+func (e *Endpoint) Healthy() bool {
+	return atomic.LoadInt32(&e.down) == 0
+}
+
+// recordProbe updates the health of endpoint with the outcome of a probe
+func (p *EndpointPool) recordProbe(endpoint *Endpoint, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ok {
+		endpoint.probeFailures = 0
+		endpoint.probeSuccesses++
+		if endpoint.probeSuccesses >= p.healthCheck.healthyThreshold {
+			atomic.StoreInt32(&endpoint.down, 0)
+		}
+		return
+	}
+
+	endpoint.probeSuccesses = 0
+	endpoint.probeFailures++
+	if endpoint.probeFailures >= p.healthCheck.unhealthyThreshold {
+		atomic.StoreInt32(&endpoint.down, 1)
+	}
+}
+
+// newHealthChecker starts probing the endpoints of pool with transport, it returns nil if the pool has no health
+// check configured
+func newHealthChecker(pool *EndpointPool, transport http.RoundTripper) *healthChecker {
+	pool.mu.Lock()
+	check := pool.healthCheck
+	pool.mu.Unlock()
+
+	if check == nil || check.interval <= 0 {
+		return nil
+	}
+
+	checker := &healthChecker{
+		pool:   pool,
+		client: &http.Client{Transport: transport, Timeout: check.interval},
+		done:   make(chan struct{}),
+	}
+
+	checker.wg.Add(1)
+	go checker.run(check)
+
+	return checker
+}
+
+// run probes every endpoint each interval until the checker is stopped
+func (h *healthChecker) run(check *healthCheck) {
+	defer h.wg.Done()
+
+	ticker := time.NewTicker(check.interval)
+	defer ticker.Stop()
+
+	for {
+		h.probeAll(check)
+
+		select {
+		case <-ticker.C:
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// probeAll probes every endpoint of the pool concurrently and waits for the results
+func (h *healthChecker) probeAll(check *healthCheck) {
+	var wg sync.WaitGroup
+
+	for _, endpoint := range h.pool.Endpoints() {
+		wg.Add(1)
+		go func(endpoint *Endpoint) {
+			defer wg.Done()
+			h.pool.recordProbe(endpoint, h.probe(endpoint, check))
+		}(endpoint)
+	}
+
+	wg.Wait()
+}
+
+// probe sends a single health check request to endpoint
+func (h *healthChecker) probe(endpoint *Endpoint, check *healthCheck) bool {
+	request, err := http.NewRequest(http.MethodGet, check.path, nil)
+	if err != nil {
+		return false
+	}
+	request.URL = endpoint.resolve(request.URL)
+
+	response, err := h.client.Do(request)
+	if err != nil {
+		return false
+	}
+	response.Body.Close()
+
+	return response.StatusCode >= 200 && response.StatusCode < 400
+}
+
+// stop stops the health checker and waits for running probes to finish
+func (h *healthChecker) stop() {
+	close(h.done)
+	h.wg.Wait()
+	h.client.CloseIdleConnections()
+}
+
+// startHealthChecks replaces the health checker of the Client with one for its current endpoint pool
+func (c *Client) startHealthChecks() {
+	c.stopHealthChecks()
+
+	if c.endpoints != nil {
+		// Probes get their own transport, so they never share connections or settings changes with requests
+		var transport http.RoundTripper = c.roundTripper
+		if transport == nil {
+			transport = c.configuredTransport().Clone()
+		}
+
+		c.healthChecker = newHealthChecker(c.endpoints, transport)
+	}
+}
+
+// stopHealthChecks stops the health checker of the Client, if any
+func (c *Client) stopHealthChecks() {
+	if c.healthChecker != nil {
+		c.healthChecker.stop()
+		c.healthChecker = nil
+	}
+}
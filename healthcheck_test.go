@@ -0,0 +1,72 @@
+package requestor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_SetEndpointPool_HealthCheck(t *testing.T) {
+	var probes, healthy int32 = 0, 1
+	flaky := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/healthz" {
+			atomic.AddInt32(&probes, 1)
+			if atomic.LoadInt32(&healthy) == 0 {
+				writer.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}
+		writer.Write([]byte("flaky"))
+	}))
+	defer flaky.Close()
+	stable := newNamedTestServer("stable")
+	defer stable.Close()
+
+	pool := NewEndpointPool(RoundRobin)
+	pool.AddEndpoint(flaky.URL, 1)
+	pool.AddEndpoint(stable.URL, 1)
+	pool.SetHealthCheck("/healthz", 10*time.Millisecond, 1, 2)
+
+	client := New()
+	client.SetEndpointPool(pool)
+
+	atomic.StoreInt32(&healthy, 0)
+	waitFor(t, func() bool { return !pool.Endpoints()[0].Healthy() })
+
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get("/", nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if got := readBody(t, resp); got != "stable/" {
+			t.Errorf("Expected: %s \n Got: %s", "stable/", got)
+		}
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	waitFor(t, func() bool { return pool.Endpoints()[0].Healthy() })
+
+	if err := client.Close(); err != nil {
+		t.Error(err)
+	}
+
+	stopped := atomic.LoadInt32(&probes)
+	time.Sleep(50 * time.Millisecond)
+	if count := atomic.LoadInt32(&probes); count != stopped {
+		t.Errorf("Expected health checks to stop after Close, got %d more probes", count-stopped)
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,186 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencySamples is the number of recent latencies kept to compute the adaptive hedging delay
+const latencySamples = 100
+
+// minLatencySamples is the number of latencies needed before the adaptive hedging delay is used
+const minLatencySamples = 10
+
+// hedging holds the hedged request settings of a Client and the latencies it observed
+type hedging struct {
+	delay      time.Duration
+	maxHedges  int
+	percentile float64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+}
+
+// hedgeResult is the outcome of a single hedged attempt
+type hedgeResult struct {
+	response *http.Response
+	err      error
+	index    int
+}
+
+// applies reports whether request may be hedged. Only safe methods are, as the server might process every attempt
+func (h *hedging) applies(request *http.Request) bool {
+	switch request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	return false
+}
+
+// observe records the latency of a completed attempt
+func (h *hedging) observe(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.latencies) < latencySamples {
+		h.latencies = append(h.latencies, latency)
+		return
+	}
+
+	h.latencies[h.next] = latency
+	h.next = (h.next + 1) % latencySamples
+}
+
+// hedgeDelay returns how long to wait for an attempt before starting the next one
+func (h *hedging) hedgeDelay() time.Duration {
+	if h.percentile <= 0 {
+		return h.delay
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.latencies) < minLatencySamples {
+		return h.delay
+	}
+
+	latencies := make([]time.Duration, len(h.latencies))
+	copy(latencies, h.latencies)
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	return latencies[int(h.percentile*float64(len(latencies)-1))]
+}
+
+// do sends request and, every time the hedge delay passes without an answer, another copy of it up to maxHedges
+// times. The first successful response wins and every other attempt is cancelled. When all attempts fail the last
+// result is returned
+func (h *hedging) do(client *http.Client, request *http.Request) (*http.Response, error) {
+	results := make(chan hedgeResult, h.maxHedges+1)
+	var cancels []context.CancelFunc
+
+	launch := func() {
+		ctx, cancel := context.WithCancel(request.Context())
+		attempt := request.Clone(ctx)
+		if request.GetBody != nil {
+			attempt.Body, _ = request.GetBody()
+		}
+
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+
+		go func() {
+			start := time.Now()
+			response, err := client.Do(attempt)
+			if err == nil {
+				h.observe(time.Since(start))
+			}
+			results <- hedgeResult{response: response, err: err, index: index}
+		}()
+	}
+
+	launch()
+	pending := 1
+
+	timer := time.NewTimer(h.hedgeDelay())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if len(cancels) <= h.maxHedges {
+				launch()
+				pending++
+				timer.Reset(h.hedgeDelay())
+			}
+		case result := <-results:
+			pending--
+
+			if pending > 0 && (result.err != nil || result.response.StatusCode >= http.StatusInternalServerError) {
+				if result.response != nil {
+					result.response.Body.Close()
+				}
+				cancels[result.index]()
+				continue
+			}
+
+			for index, cancel := range cancels {
+				if index != result.index {
+					cancel()
+				}
+			}
+
+			go discardHedges(results, pending)
+
+			if result.err != nil {
+				cancels[result.index]()
+				return nil, result.err
+			}
+
+			result.response.Body = newCallbackBody(result.response.Body, cancels[result.index])
+			return result.response, nil
+		}
+	}
+}
+
+// discardHedges closes the responses of the attempts which lost the race
+func discardHedges(results <-chan hedgeResult, pending int) {
+	for ; pending > 0; pending-- {
+		result := <-results
+		if result.response != nil {
+			result.response.Body.Close()
+		}
+	}
+}
+
+// SetHedging enables hedged requests for GET, HEAD, OPTIONS and TRACE requests. When an attempt has not been answered
+// after delay another one is sent, up to maxHedges extra attempts, and the first successful response is used while
+// the others are cancelled. A maxHedges of 0 disables hedging
+func (c *Client) SetHedging(delay time.Duration, maxHedges int) {
+	if maxHedges <= 0 {
+		c.hedging = nil
+		return
+	}
+
+	c.hedging = &hedging{delay: delay, maxHedges: maxHedges}
+}
+
+// SetHedgingPercentile makes the hedge delay follow the observed latency of the Client instead of being fixed. The
+// delay becomes the given percentile, between 0 and 1, of recent latencies, for example 0.95 for p95. The delay given
+// to SetHedging is used until enough latencies have been observed. SetHedging has to be called first
+func (c *Client) SetHedgingPercentile(percentile float64) {
+	if c.hedging == nil {
+		return
+	}
+
+	if percentile > 1 {
+		percentile = 1
+	}
+
+	c.hedging.percentile = percentile
+}
@@ -0,0 +1,105 @@
+package requestor
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_SetHedging(t *testing.T) {
+	var requests int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			select {
+			case <-request.Context().Done():
+			case <-time.After(2 * time.Second):
+			}
+			writer.Write([]byte("slow"))
+			return
+		}
+
+		writer.Write([]byte("fast"))
+	}))
+	defer testServer.Close()
+
+	client := New()
+	client.DisableKeepAlive(false)
+	client.SetHedging(50*time.Millisecond, 1)
+
+	start := time.Now()
+	resp, err := client.Get(testServer.URL, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if string(body) != "fast" {
+		t.Errorf("Expected: %s \n Got: %s", "fast", body)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected hedged request to answer early, took %s", elapsed)
+	}
+
+	if count := atomic.LoadInt32(&requests); count != 2 {
+		t.Errorf("Expected: %d \n Got: %d", 2, count)
+	}
+}
+
+func TestClient_SetHedging_UnsafeMethod(t *testing.T) {
+	var requests int32
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetHedging(10*time.Millisecond, 2)
+
+	resp, err := client.Post(testServer.URL, nil, nil, map[string]string{"hello": "world"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	resp.Body.Close()
+
+	if count := atomic.LoadInt32(&requests); count != 1 {
+		t.Errorf("Expected: %d \n Got: %d", 1, count)
+	}
+}
+
+func TestClient_SetHedgingPercentile(t *testing.T) {
+	client := New()
+	client.SetHedgingPercentile(0.95)
+
+	client.SetHedging(time.Second, 1)
+	client.SetHedgingPercentile(0.95)
+
+	if delay := client.hedging.hedgeDelay(); delay != time.Second {
+		t.Errorf("Expected: %s \n Got: %s", time.Second, delay)
+	}
+
+	for i := 1; i <= 100; i++ {
+		client.hedging.observe(time.Duration(i) * time.Millisecond)
+	}
+
+	if delay := client.hedging.hedgeDelay(); delay != 95*time.Millisecond {
+		t.Errorf("Expected: %s \n Got: %s", 95*time.Millisecond, delay)
+	}
+
+	client.SetHedging(0, 0)
+	if client.hedging != nil {
+		t.Error("Expected hedging to be disabled")
+	}
+}
@@ -0,0 +1,238 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces the values hidden from logs
+const redacted = "[REDACTED]"
+
+// defaultRedactedHeaders are always redacted from logs
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// Logger receives a log entry for every request sent by a Client, as a message followed by alternating keys and
+// values. *slog.Logger satisfies it, as do most structured loggers
+type Logger interface {
+	Info(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// LogOptions tunes what the request logs of a Client contain
+type LogOptions struct {
+	// MaxBodySize is how many bytes of the request and response bodies are logged, 0 means bodies are not logged
+	MaxBodySize int
+	// RedactHeaders lists headers whose values are hidden, on top of Authorization, Proxy-Authorization, Cookie and
+	// Set-Cookie which are always hidden
+	RedactHeaders []string
+	// RedactQueryParams lists query params whose values are hidden from the logged URL
+	RedactQueryParams []string
+	// RedactJSONFields lists JSON fields whose values are hidden from logged bodies, at any depth
+	RedactJSONFields []string
+}
+
+// requestLogger logs requests with the sensitive parts redacted
+type requestLogger struct {
+	logger      Logger
+	maxBodySize int
+	headers     map[string]bool
+	queryParams map[string]bool
+	jsonFields  *regexp.Regexp
+}
+
+// newRequestLogger creates a requestLogger logging to logger according to options
+func newRequestLogger(logger Logger, options LogOptions) *requestLogger {
+	requestLogger := &requestLogger{
+		logger:      logger,
+		maxBodySize: options.MaxBodySize,
+		headers:     make(map[string]bool),
+		queryParams: make(map[string]bool),
+	}
+
+	for _, header := range append(defaultRedactedHeaders, options.RedactHeaders...) {
+		requestLogger.headers[http.CanonicalHeaderKey(header)] = true
+	}
+
+	for _, param := range options.RedactQueryParams {
+		requestLogger.queryParams[param] = true
+	}
+
+	if len(options.RedactJSONFields) > 0 {
+		fields := make([]string, 0, len(options.RedactJSONFields))
+		for _, field := range options.RedactJSONFields {
+			fields = append(fields, regexp.QuoteMeta(field))
+		}
+
+		// Matching the text rather than decoding it keeps redaction working on bodies truncated to MaxBodySize
+		requestLogger.jsonFields = regexp.MustCompile(`("(?:` + strings.Join(fields, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]*)`)
+	}
+
+	return requestLogger
+}
+
+// redactURL returns requestURL with its password and redacted query params hidden
+func (l *requestLogger) redactURL(requestURL *url.URL) string {
+	redactedURL := *requestURL
+
+	if len(l.queryParams) > 0 && redactedURL.RawQuery != "" {
+		query := redactedURL.Query()
+		for param := range query {
+			if l.queryParams[param] {
+				query[param] = []string{redacted}
+			}
+		}
+		redactedURL.RawQuery = query.Encode()
+	}
+
+	return redactedURL.Redacted()
+}
+
+// redactHeader returns a copy of header with the redacted headers hidden
+func (l *requestLogger) redactHeader(header http.Header) http.Header {
+	redactedHeader := header.Clone()
+
+	for field := range redactedHeader {
+		if l.headers[field] {
+			redactedHeader[field] = []string{redacted}
+		}
+	}
+
+	return redactedHeader
+}
+
+// redactBody returns body with the redacted JSON fields hidden
+func (l *requestLogger) redactBody(body []byte) string {
+	if l.jsonFields == nil {
+		return string(body)
+	}
+
+	return l.jsonFields.ReplaceAllString(string(body), `${1}"`+redacted+`"`)
+}
+
+// requestBody returns the start of the body of request, without consuming it
+func (l *requestLogger) requestBody(request *http.Request) string {
+	if l.maxBodySize <= 0 || request.GetBody == nil {
+		return ""
+	}
+
+	body, err := request.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	start, _ := ioutil.ReadAll(io.LimitReader(body, int64(l.maxBodySize)))
+
+	return l.redactBody(start)
+}
+
+// loggedBody counts the bytes read from a response body and keeps the first ones, calling done once the body is
+// read to the end or closed
+type loggedBody struct {
+	io.ReadCloser
+	size     int64
+	captured bytes.Buffer
+	limit    int
+	once     sync.Once
+	done     func(body *loggedBody)
+}
+
+// Read implements io.Reader
+func (b *loggedBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.size += int64(n)
+
+	if room := b.limit - b.captured.Len(); room > 0 {
+		if room > n {
+			room = n
+		}
+		b.captured.Write(p[:room])
+	}
+
+	if err == io.EOF {
+		b.once.Do(func() { b.done(b) })
+	}
+
+	return n, err
+}
+
+// Close implements io.Closer
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b) })
+
+	return err
+}
+
+// loggingTransport logs every request sent over the network along with its response
+type loggingTransport struct {
+	next   http.RoundTripper
+	logger *requestLogger
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *loggingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	args := []interface{}{
+		"method", request.Method,
+		"url", t.logger.redactURL(request.URL),
+		"attempt", stateFromContext(request.Context()).currentAttempt(),
+		"request_headers", t.logger.redactHeader(request.Header),
+		"request_size", request.ContentLength,
+	}
+
+	if t.logger.maxBodySize > 0 {
+		args = append(args, "request_body", t.logger.requestBody(request))
+	}
+
+	start := time.Now()
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		t.logger.logger.Error("request failed", append(args, "duration", time.Since(start), "error", err)...)
+		return nil, err
+	}
+
+	args = append(args,
+		"status", response.StatusCode,
+		"response_headers", t.logger.redactHeader(response.Header),
+	)
+
+	if response.Body == nil {
+		t.logger.logger.Info("request completed", append(args, "duration", time.Since(start), "response_size", 0)...)
+		return response, nil
+	}
+
+	response.Body = &loggedBody{
+		ReadCloser: response.Body,
+		limit:      t.logger.maxBodySize,
+		done: func(body *loggedBody) {
+			args = append(args, "duration", time.Since(start), "response_size", body.size)
+			if t.logger.maxBodySize > 0 {
+				args = append(args, "response_body", t.logger.redactBody(body.captured.Bytes()))
+			}
+
+			t.logger.logger.Info("request completed", args...)
+		},
+	}
+
+	return response, nil
+}
+
+// SetLogger makes the Client log every request it sends to logger, once the response body is read to the end or
+// closed. Entries hold the method, URL, attempt number, headers, status, duration and sizes, along with the start of
+// the bodies when options.MaxBodySize is set. A nil logger disables logging
+func (c *Client) SetLogger(logger Logger, options LogOptions) {
+	if logger == nil {
+		c.requestLogger = nil
+		return
+	}
+
+	c.requestLogger = newRequestLogger(logger, options)
+}
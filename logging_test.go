@@ -0,0 +1,136 @@
+package requestor
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type testLogEntry struct {
+	level string
+	msg   string
+	attrs map[string]interface{}
+}
+
+type testLogger struct {
+	mu      sync.Mutex
+	entries []testLogEntry
+}
+
+func (l *testLogger) log(level, msg string, args []interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	attrs := make(map[string]interface{})
+	for i := 0; i+1 < len(args); i += 2 {
+		attrs[args[i].(string)] = args[i+1]
+	}
+
+	l.entries = append(l.entries, testLogEntry{level: level, msg: msg, attrs: attrs})
+}
+
+func (l *testLogger) Info(msg string, args ...interface{}) {
+	l.log("info", msg, args)
+}
+
+func (l *testLogger) Error(msg string, args ...interface{}) {
+	l.log("error", msg, args)
+}
+
+func TestClient_SetLogger(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		http.SetCookie(writer, &http.Cookie{Name: "session", Value: "secret-cookie"})
+		writer.Write([]byte(`{"token": "secret-token", "name": "gopher"}`))
+	}))
+	defer testServer.Close()
+
+	logger := &testLogger{}
+	client := New()
+	client.SetLogger(logger, LogOptions{
+		MaxBodySize:       1024,
+		RedactHeaders:     []string{"X-Api-Key"},
+		RedactQueryParams: []string{"api_key"},
+		RedactJSONFields:  []string{"password", "token"},
+	})
+
+	headers := map[string][]string{
+		"Authorization": {"Bearer secret-bearer"},
+		"X-Api-Key":     {"secret-key"},
+		"Content-Type":  {"application/json"},
+	}
+	queryParams := map[string][]string{
+		"api_key": {"secret-param"},
+		"page":    {"2"},
+	}
+
+	resp, err := client.Post(testServer.URL, headers, queryParams, map[string]string{"password": "secret-password", "user": "gopher"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	readBody(t, resp)
+
+	if len(logger.entries) != 1 {
+		t.Errorf("Expected: %d \n Got: %d", 1, len(logger.entries))
+		return
+	}
+
+	entry := logger.entries[0]
+	logged := fmt.Sprint(entry.attrs)
+	if strings.Contains(logged, "secret") {
+		t.Errorf("Expected secrets to be redacted, got %s", logged)
+	}
+
+	expected := map[string]interface{}{
+		"method":        http.MethodPost,
+		"status":        http.StatusOK,
+		"attempt":       1,
+		"response_size": int64(43),
+		"response_body": `{"token": "[REDACTED]", "name": "gopher"}`,
+		"request_body":  `{"password":"[REDACTED]","user":"gopher"}`,
+	}
+
+	for key, value := range expected {
+		if entry.attrs[key] != value {
+			t.Errorf("%s: Expected: %v \n Got: %v", key, value, entry.attrs[key])
+		}
+	}
+
+	if url := entry.attrs["url"].(string); !strings.Contains(url, "page=2") || !strings.Contains(url, "api_key=%5BREDACTED%5D") {
+		t.Errorf("Expected query params to be redacted, got %s", url)
+	}
+
+	client.SetLogger(nil, LogOptions{})
+	if client.requestLogger != nil {
+		t.Error("Expected logging to be disabled")
+	}
+}
+
+func TestClient_SetLogger_Error(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	testServer.Close()
+
+	logger := &testLogger{}
+	client := New()
+	client.SetMaxRetries(2, 0)
+	client.TimeBetweenRetries = 0
+	client.SetLogger(logger, LogOptions{})
+
+	if _, err := client.Get(testServer.URL, nil, nil); err == nil {
+		t.Error("Expected request to a closed server to fail")
+	}
+
+	if len(logger.entries) != 2 {
+		t.Errorf("Expected: %d \n Got: %d", 2, len(logger.entries))
+		return
+	}
+
+	for i, entry := range logger.entries {
+		if entry.level != "error" || entry.attrs["attempt"] != i+1 {
+			t.Errorf("Expected failed attempt %d to be logged as an error, got %+v", i+1, entry)
+		}
+	}
+}
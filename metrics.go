@@ -0,0 +1,349 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the latency histogram of Metrics
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// RequestMetrics are the measurements of a single request sent over the network
+type RequestMetrics struct {
+	Host   string
+	Method string
+	// StatusCode is 0 when the request failed without a response
+	StatusCode int
+	Err        error
+	// Duration runs from sending the request until its response body is read to the end or closed
+	Duration      time.Duration
+	BytesSent     int64
+	BytesReceived int64
+	// ConnectionReused reports whether the request went over a connection from the pool
+	ConnectionReused bool
+	// Retry reports whether the request is a retry of a failed attempt
+	Retry bool
+}
+
+// MetricsCollector receives the measurements of every request sent by a Client. Implement it to feed any metrics
+// backend, or use Metrics
+type MetricsCollector interface {
+	ObserveRequest(metrics RequestMetrics)
+}
+
+// MetricType is the kind of a Metric
+type MetricType int
+
+const (
+	// CounterMetric is a value that only goes up
+	CounterMetric MetricType = iota
+	// HistogramMetric counts observations into buckets
+	HistogramMetric
+)
+
+// Metric is a single time series of Metrics, as handed out by Collect
+type Metric struct {
+	Name   string
+	Help   string
+	Type   MetricType
+	Labels map[string]string
+	// Value is the value of a counter
+	Value float64
+	// Buckets maps the upper bound of each histogram bucket to the cumulative count of observations in it
+	Buckets map[float64]uint64
+	// Count and Sum are the number and the sum of the observations of a histogram
+	Count uint64
+	Sum   float64
+}
+
+// metricHelp describes every metric of Metrics
+var metricHelp = map[string]string{
+	"requestor_requests_total":           "Requests sent, by host, method and status.",
+	"requestor_retries_total":            "Retries of failed requests, by host and method.",
+	"requestor_request_duration_seconds": "Request latency until the response body is consumed, by host and method.",
+	"requestor_request_bytes_total":      "Request body bytes sent, by host and method.",
+	"requestor_response_bytes_total":     "Response body bytes received, by host and method.",
+	"requestor_connections_total":        "Connections used by requests, by whether they were reused from the pool.",
+}
+
+// series is the state of a single time series
+type series struct {
+	name    string
+	labels  map[string]string
+	value   float64
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+// Metrics is a MetricsCollector keeping request counters and latency histograms in memory. It exposes them through
+// Collect, in a way that maps directly to a Prometheus collector, and WriteTo in the Prometheus text format
+type Metrics struct {
+	mu      sync.Mutex
+	buckets []float64
+	series  map[string]*series
+}
+
+// NewMetrics creates an empty Metrics using buckets as the latency histogram bounds in seconds, nil means
+// DefaultLatencyBuckets
+func NewMetrics(buckets []float64) *Metrics {
+	if buckets == nil {
+		buckets = DefaultLatencyBuckets
+	}
+
+	sorted := make([]float64, len(buckets))
+	copy(sorted, buckets)
+	sort.Float64s(sorted)
+
+	return &Metrics{buckets: sorted, series: make(map[string]*series)}
+}
+
+// get returns the series of name with labels, creating it on first use. The caller must hold the lock
+func (m *Metrics) get(name string, labels ...string) *series {
+	var key strings.Builder
+	key.WriteString(name)
+
+	labelMap := make(map[string]string, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		labelMap[labels[i]] = labels[i+1]
+		key.WriteString("\xff" + labels[i] + "=" + labels[i+1])
+	}
+
+	s, ok := m.series[key.String()]
+	if !ok {
+		s = &series{name: name, labels: labelMap}
+		m.series[key.String()] = s
+	}
+
+	return s
+}
+
+// observe adds value to the histogram of name with labels. The caller must hold the lock
+func (m *Metrics) observe(value float64, name string, labels ...string) {
+	s := m.get(name, labels...)
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(m.buckets))
+	}
+
+	for i, bound := range m.buckets {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+
+	s.count++
+	s.sum += value
+}
+
+// ObserveRequest implements MetricsCollector
+func (m *Metrics) ObserveRequest(metrics RequestMetrics) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := "error"
+	if metrics.Err == nil {
+		status = strconv.Itoa(metrics.StatusCode)
+	}
+
+	m.get("requestor_requests_total", "host", metrics.Host, "method", metrics.Method, "status", status).value++
+	m.observe(metrics.Duration.Seconds(), "requestor_request_duration_seconds", "host", metrics.Host, "method", metrics.Method)
+	m.get("requestor_request_bytes_total", "host", metrics.Host, "method", metrics.Method).value += float64(metrics.BytesSent)
+	m.get("requestor_response_bytes_total", "host", metrics.Host, "method", metrics.Method).value += float64(metrics.BytesReceived)
+
+	if metrics.Retry {
+		m.get("requestor_retries_total", "host", metrics.Host, "method", metrics.Method).value++
+	}
+
+	if metrics.Err == nil {
+		m.get("requestor_connections_total", "reused", strconv.FormatBool(metrics.ConnectionReused)).value++
+	}
+}
+
+// Collect sends every time series to ch, sorted by name and labels
+func (m *Metrics) Collect(ch chan<- Metric) {
+	for _, metric := range m.snapshot() {
+		ch <- metric
+	}
+}
+
+// snapshot returns a copy of every time series, sorted by name and labels
+func (m *Metrics) snapshot() []Metric {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	metrics := make([]Metric, 0, len(keys))
+	for _, key := range keys {
+		s := m.series[key]
+
+		labels := make(map[string]string, len(s.labels))
+		for name, value := range s.labels {
+			labels[name] = value
+		}
+
+		metric := Metric{Name: s.name, Help: metricHelp[s.name], Labels: labels, Value: s.value}
+		if s.buckets != nil {
+			metric.Type = HistogramMetric
+			metric.Buckets = make(map[float64]uint64, len(m.buckets))
+			for i, bound := range m.buckets {
+				metric.Buckets[bound] = s.buckets[i]
+			}
+			metric.Count = s.count
+			metric.Sum = s.sum
+		}
+
+		metrics = append(metrics, metric)
+	}
+
+	return metrics
+}
+
+// formatLabels formats labels in the Prometheus text format, extra holds additional label pairs
+func formatLabels(labels map[string]string, extra ...string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(names)+len(extra)/2)
+	for _, name := range names {
+		pairs = append(pairs, name+"="+strconv.Quote(labels[name]))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+"="+strconv.Quote(extra[i+1]))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// WriteTo writes every time series to w in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var buffer bytes.Buffer
+	described := make(map[string]bool)
+
+	for _, metric := range m.snapshot() {
+		if !described[metric.Name] {
+			described[metric.Name] = true
+
+			metricType := "counter"
+			if metric.Type == HistogramMetric {
+				metricType = "histogram"
+			}
+			fmt.Fprintf(&buffer, "# HELP %s %s\n# TYPE %s %s\n", metric.Name, metric.Help, metric.Name, metricType)
+		}
+
+		if metric.Type == CounterMetric {
+			fmt.Fprintf(&buffer, "%s%s %s\n", metric.Name, formatLabels(metric.Labels), formatFloat(metric.Value))
+			continue
+		}
+
+		bounds := make([]float64, 0, len(metric.Buckets))
+		for bound := range metric.Buckets {
+			bounds = append(bounds, bound)
+		}
+		sort.Float64s(bounds)
+
+		for _, bound := range bounds {
+			fmt.Fprintf(&buffer, "%s_bucket%s %d\n", metric.Name, formatLabels(metric.Labels, "le", formatFloat(bound)), metric.Buckets[bound])
+		}
+		fmt.Fprintf(&buffer, "%s_bucket%s %d\n", metric.Name, formatLabels(metric.Labels, "le", "+Inf"), metric.Count)
+		fmt.Fprintf(&buffer, "%s_sum%s %s\n", metric.Name, formatLabels(metric.Labels), formatFloat(metric.Sum))
+		fmt.Fprintf(&buffer, "%s_count%s %d\n", metric.Name, formatLabels(metric.Labels), metric.Count)
+	}
+
+	return buffer.WriteTo(w)
+}
+
+// formatFloat formats a metric value the way Prometheus does
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// metricsTransport measures every request sent over the network
+type metricsTransport struct {
+	next      http.RoundTripper
+	collector MetricsCollector
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *metricsTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	metrics := RequestMetrics{
+		Host:   request.URL.Host,
+		Method: request.Method,
+		Retry:  request.Response == nil && stateFromContext(request.Context()).currentAttempt() > 1,
+	}
+
+	if request.ContentLength > 0 {
+		metrics.BytesSent = request.ContentLength
+	}
+
+	var mu sync.Mutex
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			mu.Lock()
+			metrics.ConnectionReused = info.Reused
+			mu.Unlock()
+		},
+	}
+	request = request.WithContext(httptrace.WithClientTrace(request.Context(), trace))
+
+	start := time.Now()
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		mu.Lock()
+		metrics.Err = err
+		metrics.Duration = time.Since(start)
+		mu.Unlock()
+
+		t.collector.ObserveRequest(metrics)
+		return nil, err
+	}
+
+	metrics.StatusCode = response.StatusCode
+
+	if response.Body == nil {
+		metrics.Duration = time.Since(start)
+		t.collector.ObserveRequest(metrics)
+		return response, nil
+	}
+
+	response.Body = &loggedBody{
+		ReadCloser: response.Body,
+		done: func(body *loggedBody) {
+			mu.Lock()
+			metrics.Duration = time.Since(start)
+			metrics.BytesReceived = body.size
+			observed := metrics
+			mu.Unlock()
+
+			t.collector.ObserveRequest(observed)
+		},
+	}
+
+	return response, nil
+}
+
+// SetMetrics makes the Client report the measurements of every request it sends over the network to collector. A
+// nil collector disables metrics
+func (c *Client) SetMetrics(collector MetricsCollector) {
+	c.metrics = collector
+}
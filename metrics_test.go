@@ -0,0 +1,123 @@
+package requestor
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func collectMetrics(metrics *Metrics) map[string]Metric {
+	ch := make(chan Metric, 100)
+	metrics.Collect(ch)
+	close(ch)
+
+	collected := make(map[string]Metric)
+	for metric := range ch {
+		collected[metric.Name+formatLabels(metric.Labels)] = metric
+	}
+
+	return collected
+}
+
+func TestClient_SetMetrics(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("hello"))
+	}))
+	defer testServer.Close()
+
+	metrics := NewMetrics(nil)
+	client := New()
+	client.DisableKeepAlive(false)
+	client.SetMetrics(metrics)
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Post(testServer.URL, map[string][]string{"Content-Type": {"application/json"}}, nil, map[string]string{"a": "b"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		readBody(t, resp)
+	}
+
+	host := strings.TrimPrefix(testServer.URL, "http://")
+	labels := `{host="` + host + `",method="POST"`
+	collected := collectMetrics(metrics)
+
+	if requests := collected[`requestor_requests_total`+labels+`,status="200"}`].Value; requests != 2 {
+		t.Errorf("Expected: %d \n Got: %v", 2, requests)
+	}
+
+	if received := collected[`requestor_response_bytes_total`+labels+`}`].Value; received != 10 {
+		t.Errorf("Expected: %d \n Got: %v", 10, received)
+	}
+
+	if sent := collected[`requestor_request_bytes_total`+labels+`}`].Value; sent != 18 {
+		t.Errorf("Expected: %d \n Got: %v", 18, sent)
+	}
+
+	latency := collected[`requestor_request_duration_seconds`+labels+`}`]
+	if latency.Type != HistogramMetric || latency.Count != 2 || latency.Buckets[10] != 2 {
+		t.Errorf("Expected a latency histogram with 2 observations, got %+v", latency)
+	}
+
+	if reused := collected[`requestor_connections_total{reused="true"}`].Value; reused != 1 {
+		t.Errorf("Expected: %d \n Got: %v", 1, reused)
+	}
+
+	var buffer bytes.Buffer
+	if _, err := metrics.WriteTo(&buffer); err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, line := range []string{
+		"# TYPE requestor_request_duration_seconds histogram",
+		`requestor_request_duration_seconds_bucket` + labels + `,le="+Inf"} 2`,
+		`requestor_requests_total` + labels + `,status="200"} 2`,
+	} {
+		if !strings.Contains(buffer.String(), line+"\n") {
+			t.Errorf("Expected exposition to contain %q, got \n%s", line, buffer.String())
+		}
+	}
+}
+
+func TestClient_SetMetrics_Retries(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	testServer.Close()
+
+	metrics := NewMetrics([]float64{1})
+	client := New()
+	client.SetMaxRetries(3, 0)
+	client.TimeBetweenRetries = 0
+	client.SetMetrics(metrics)
+
+	if _, err := client.Get(testServer.URL, nil, nil); err == nil {
+		t.Error("Expected request to a closed server to fail")
+	}
+
+	labels := `{host="` + strings.TrimPrefix(testServer.URL, "http://") + `",method="GET"`
+	collected := collectMetrics(metrics)
+
+	if failed := collected[`requestor_requests_total`+labels+`,status="error"}`].Value; failed != 3 {
+		t.Errorf("Expected: %d \n Got: %v", 3, failed)
+	}
+
+	if retries := collected[`requestor_retries_total`+labels+`}`].Value; retries != 2 {
+		t.Errorf("Expected: %d \n Got: %v", 2, retries)
+	}
+}
+
+func TestMetrics_ObserveRequest(t *testing.T) {
+	metrics := NewMetrics([]float64{1, 0.1})
+	metrics.ObserveRequest(RequestMetrics{Host: "example.com", Method: http.MethodGet, StatusCode: 200, Duration: 50 * time.Millisecond})
+	metrics.ObserveRequest(RequestMetrics{Host: "example.com", Method: http.MethodGet, StatusCode: 200, Duration: 500 * time.Millisecond})
+	metrics.ObserveRequest(RequestMetrics{Host: "example.com", Method: http.MethodGet, StatusCode: 200, Duration: 5 * time.Second})
+
+	latency := collectMetrics(metrics)[`requestor_request_duration_seconds{host="example.com",method="GET"}`]
+	if latency.Buckets[0.1] != 1 || latency.Buckets[1] != 2 || latency.Count != 3 {
+		t.Errorf("Expected cumulative buckets 1, 2 and 3, got %+v", latency)
+	}
+}
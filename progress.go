@@ -0,0 +1,157 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ProgressDirection tells whether a Progress reports a request body or a response body
+type ProgressDirection int
+
+const (
+	// ProgressUpload reports the body of a request being sent
+	ProgressUpload ProgressDirection = iota
+	// ProgressDownload reports the body of a response being read
+	ProgressDownload
+)
+
+// String implements fmt.Stringer
+func (d ProgressDirection) String() string {
+	if d == ProgressUpload {
+		return "upload"
+	}
+
+	return "download"
+}
+
+// Progress is a report on the transfer of a request or response body
+type Progress struct {
+	Request   *http.Request
+	Direction ProgressDirection
+	// Transferred is the number of bytes transferred so far
+	Transferred int64
+	// Total is the size of the body, -1 when it is unknown
+	Total int64
+	// Rate is the average speed of the transfer so far in bytes per second
+	Rate float64
+	// Done is set on the last report of a body, once it was transferred entirely or closed
+	Done bool
+}
+
+// ProgressFunc receives progress reports, calls for a given body never overlap
+type ProgressFunc func(progress Progress)
+
+// progressBody reports the bytes read from a body to a ProgressFunc, at most once per interval
+type progressBody struct {
+	io.ReadCloser
+	progress ProgressFunc
+	interval time.Duration
+
+	mu       sync.Mutex
+	report   Progress
+	start    time.Time
+	reported time.Time
+}
+
+// newProgressBody wraps body, which is total bytes long or -1 if unknown
+func newProgressBody(body io.ReadCloser, request *http.Request, direction ProgressDirection, total int64,
+	progress ProgressFunc, interval time.Duration) *progressBody {
+	now := time.Now()
+
+	return &progressBody{
+		ReadCloser: body,
+		progress:   progress,
+		interval:   interval,
+		report:     Progress{Request: request, Direction: direction, Total: total},
+		start:      now,
+		reported:   now,
+	}
+}
+
+// Read implements io.Reader
+func (b *progressBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.report.Transferred += int64(n)
+	b.notify(err == io.EOF || b.report.Transferred == b.report.Total)
+
+	return n, err
+}
+
+// Close implements io.Closer, sending the last report if the body was not read to the end
+func (b *progressBody) Close() error {
+	err := b.ReadCloser.Close()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.notify(true)
+
+	return err
+}
+
+// notify calls the ProgressFunc if the interval elapsed since the last report, or if done. Nothing is reported after
+// the last report
+func (b *progressBody) notify(done bool) {
+	if b.report.Done {
+		return
+	}
+
+	now := time.Now()
+	if !done && now.Sub(b.reported) < b.interval {
+		return
+	}
+
+	if elapsed := now.Sub(b.start).Seconds(); elapsed > 0 {
+		b.report.Rate = float64(b.report.Transferred) / elapsed
+	}
+	b.report.Done = done
+	b.reported = now
+
+	b.progress(b.report)
+}
+
+// progressTransport reports the progress of request and response bodies
+type progressTransport struct {
+	next     http.RoundTripper
+	progress ProgressFunc
+	interval time.Duration
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *progressTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Body != nil && request.Body != http.NoBody {
+		total := request.ContentLength
+		if total <= 0 {
+			total = -1
+		}
+
+		request = request.Clone(request.Context())
+		request.Body = newProgressBody(request.Body, request, ProgressUpload, total, t.progress, t.interval)
+	}
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		return response, err
+	}
+
+	if request.Method != http.MethodHead && response.Body != nil && response.Body != http.NoBody {
+		response.Body = newProgressBody(response.Body, request, ProgressDownload, response.ContentLength, t.progress, t.interval)
+	}
+
+	return response, nil
+}
+
+// SetProgress reports the progress of every request and response body to progress, at most once per interval plus a
+// last report when the body is done. Retried requests and resumed downloads report each attempt from 0. A nil
+// progress stops the reports
+func (c *Client) SetProgress(progress ProgressFunc, interval time.Duration) {
+	c.progress = progress
+	c.progressInterval = interval
+}
@@ -0,0 +1,118 @@
+package requestor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_SetProgress(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ioutil.ReadAll(request.Body)
+		writer.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		writer.Write(content)
+	}))
+	defer testServer.Close()
+
+	var mu sync.Mutex
+	var reports []Progress
+	client := New()
+	client.SetProgress(func(progress Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		reports = append(reports, progress)
+	}, 0)
+
+	headers := map[string][]string{"Content-Type": {"application/json"}}
+	resp, err := client.Post(testServer.URL, headers, nil, map[string]string{"name": "requestor"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if len(body) != len(content) {
+		t.Errorf("Expected: %d \n Got: %d", len(content), len(body))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var uploads, downloads []Progress
+	for _, report := range reports {
+		if report.Direction == ProgressUpload {
+			uploads = append(uploads, report)
+		} else {
+			downloads = append(downloads, report)
+		}
+	}
+
+	if len(uploads) == 0 || len(downloads) < 2 {
+		t.Errorf("Expected upload and download reports, got %d and %d", len(uploads), len(downloads))
+		return
+	}
+
+	last := uploads[len(uploads)-1]
+	if !last.Done || last.Transferred != 20 || last.Total != 20 {
+		t.Errorf("Expected: 20/20 done \n Got: %d/%d done=%t", last.Transferred, last.Total, last.Done)
+	}
+
+	last = downloads[len(downloads)-1]
+	if !last.Done || last.Transferred != int64(len(content)) || last.Total != int64(len(content)) || last.Rate <= 0 {
+		t.Errorf("Expected: %d/%d done \n Got: %d/%d done=%t rate=%f", len(content), len(content), last.Transferred,
+			last.Total, last.Done, last.Rate)
+	}
+
+	for i, report := range downloads[:len(downloads)-1] {
+		if report.Done || report.Transferred > downloads[i+1].Transferred {
+			t.Errorf("Expected increasing reports before the last one, got %+v", downloads)
+			break
+		}
+	}
+}
+
+func TestClient_SetProgress_Interval(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		for i := 0; i < 5; i++ {
+			writer.Write([]byte("chunk"))
+			writer.(http.Flusher).Flush()
+		}
+	}))
+	defer testServer.Close()
+
+	var mu sync.Mutex
+	var reports []Progress
+	client := New()
+	client.SetProgress(func(progress Progress) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		reports = append(reports, progress)
+	}, time.Hour)
+
+	resp, err := client.Get(testServer.URL, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// Only the last report gets through the interval
+	if len(reports) != 1 || !reports[0].Done || reports[0].Transferred != 25 || reports[0].Total != -1 {
+		t.Errorf("Expected a single report of 25 unknown bytes, got %+v", reports)
+	}
+}
@@ -22,7 +22,7 @@ func (c *Client) SetHTTPProxy(proxyURL, username, password string) {
 		Proxy: http.ProxyURL(proxyConfig),
 	}
 
-	c.transport = transport
+	c.setTransport(transport)
 }
 
 // SetHTTPSProxy sets a HTTP proxy to the transport, proxyURL is a required parameter, but username and password
@@ -41,5 +41,5 @@ func (c *Client) SetHTTPSProxy(proxyURL, username, password string) {
 		Proxy: http.ProxyURL(proxyConfig),
 	}
 
-	c.transport = transport
+	c.setTransport(transport)
 }
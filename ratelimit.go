@@ -0,0 +1,343 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when a request would exceed a rate limit and the Client is set to fail fast
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// rateLimiter is a token bucket which refills at rate tokens per second and holds at most burst tokens. Every
+// request takes one token
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a rateLimiter allowing rate requests per second with bursts of up to burst requests. A burst
+// smaller than 1 is treated as 1, a rate of 0 or less allows every request
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// refill adds the tokens earned since the last call, the caller must hold the lock
+func (l *rateLimiter) refill(now time.Time) {
+	if now.After(l.last) {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait before it may be used
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return 0
+	}
+
+	l.refill(now)
+	l.tokens--
+
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// allow takes a token only if one is available right now
+func (l *rateLimiter) allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return true
+	}
+
+	l.refill(now)
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
+
+// cancel gives back a token taken by reserve or allow which ended up not being used
+func (l *rateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return
+	}
+
+	l.tokens++
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+// routeLimiter is a rateLimiter applied to requests whose host and path match pattern
+type routeLimiter struct {
+	pattern string
+	limiter *rateLimiter
+}
+
+// rateLimits holds every rate limit configured on a Client
+type rateLimits struct {
+	mu          sync.Mutex
+	global      *rateLimiter
+	hosts       map[string]*rateLimiter
+	routes      []routeLimiter
+	failFast    bool
+	adaptive    bool
+	pausedUntil map[string]time.Time
+}
+
+// limitersFor returns the limiters that apply to request
+func (r *rateLimits) limitersFor(request *http.Request) (limiters []*rateLimiter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.global != nil {
+		limiters = append(limiters, r.global)
+	}
+
+	if limiter, ok := r.hosts[request.URL.Hostname()]; ok {
+		limiters = append(limiters, limiter)
+	}
+
+	route := request.URL.Hostname() + request.URL.EscapedPath()
+	for _, routeLimiter := range r.routes {
+		if matched, _ := path.Match(routeLimiter.pattern, route); matched {
+			limiters = append(limiters, routeLimiter.limiter)
+		}
+	}
+
+	return limiters
+}
+
+// pause returns the time until which the server asked us to stop sending requests to host
+func (r *rateLimits) pause(host string) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.pausedUntil[host]
+}
+
+// wait blocks until request is allowed by every applicable limiter or its context is done. When failFast is set it
+// returns ErrRateLimited instead of blocking
+func (r *rateLimits) wait(request *http.Request) error {
+	now := time.Now()
+	limiters := r.limitersFor(request)
+
+	r.mu.Lock()
+	failFast := r.failFast
+	r.mu.Unlock()
+
+	if failFast {
+		if now.Before(r.pause(request.URL.Hostname())) {
+			return ErrRateLimited
+		}
+
+		for i, limiter := range limiters {
+			if !limiter.allow(now) {
+				for _, taken := range limiters[:i] {
+					taken.cancel()
+				}
+				return ErrRateLimited
+			}
+		}
+
+		return nil
+	}
+
+	delay := r.pause(request.URL.Hostname()).Sub(now)
+	for _, limiter := range limiters {
+		if wait := limiter.reserve(now); wait > delay {
+			delay = wait
+		}
+	}
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-request.Context().Done():
+		for _, limiter := range limiters {
+			limiter.cancel()
+		}
+		return request.Context().Err()
+	}
+}
+
+// observe pauses the host of request until the advertised reset time when the server reports that no requests
+// remain in the current window through the X-RateLimit-Remaining and X-RateLimit-Reset headers
+func (r *rateLimits) observe(request *http.Request, response *http.Response) {
+	if response == nil {
+		return
+	}
+
+	r.mu.Lock()
+	adaptive := r.adaptive
+	r.mu.Unlock()
+
+	if !adaptive {
+		return
+	}
+
+	if response.Header.Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+
+	reset, err := strconv.ParseInt(response.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || reset <= 0 {
+		return
+	}
+
+	// Some APIs send the reset as a unix timestamp and others as the number of seconds left in the window
+	var until time.Time
+	if reset > 1000000000 {
+		until = time.Unix(reset, 0)
+	} else {
+		until = time.Now().Add(time.Duration(reset) * time.Second)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pausedUntil == nil {
+		r.pausedUntil = make(map[string]time.Time)
+	}
+	r.pausedUntil[request.URL.Hostname()] = until
+}
+
+// rateLimitTransport delays or rejects requests exceeding the configured rate limits
+type rateLimitTransport struct {
+	next   http.RoundTripper
+	limits *rateLimits
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *rateLimitTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if err := t.limits.wait(request); err != nil {
+		return nil, err
+	}
+
+	response, err := t.next.RoundTrip(request)
+	if err == nil {
+		t.limits.observe(request, response)
+	}
+
+	return response, err
+}
+
+// limits returns the rate limits of the Client, creating them on first use
+func (c *Client) limits() *rateLimits {
+	if c.rateLimits == nil {
+		c.rateLimits = &rateLimits{hosts: make(map[string]*rateLimiter)}
+	}
+
+	return c.rateLimits
+}
+
+// SetRateLimit limits all requests made by the Client to rate requests per second with bursts of up to burst requests.
+// A rate of 0 or less removes the limit
+func (c *Client) SetRateLimit(rate float64, burst int) {
+	limits := c.limits()
+	limits.mu.Lock()
+	defer limits.mu.Unlock()
+
+	if rate <= 0 {
+		limits.global = nil
+		return
+	}
+
+	limits.global = newRateLimiter(rate, burst)
+}
+
+// SetHostRateLimit limits requests to host to rate requests per second with bursts of up to burst requests. host is
+// compared against the hostname of the request URL, without the port. A rate of 0 or less removes the limit
+func (c *Client) SetHostRateLimit(host string, rate float64, burst int) {
+	limits := c.limits()
+	limits.mu.Lock()
+	defer limits.mu.Unlock()
+
+	if rate <= 0 {
+		delete(limits.hosts, host)
+		return
+	}
+
+	limits.hosts[host] = newRateLimiter(rate, burst)
+}
+
+// SetRouteRateLimit limits requests matching pattern to rate requests per second with bursts of up to burst requests.
+// pattern uses the path.Match syntax and is matched against the hostname, without the port, and path of the request,
+// for example "api.example.com/v1/users/*". A rate of 0 or less removes the limits set for pattern
+func (c *Client) SetRouteRateLimit(pattern string, rate float64, burst int) {
+	limits := c.limits()
+	limits.mu.Lock()
+	defer limits.mu.Unlock()
+
+	if rate <= 0 {
+		routes := limits.routes[:0]
+		for _, route := range limits.routes {
+			if route.pattern != pattern {
+				routes = append(routes, route)
+			}
+		}
+		limits.routes = routes
+		return
+	}
+
+	limits.routes = append(limits.routes, routeLimiter{pattern: pattern, limiter: newRateLimiter(rate, burst)})
+}
+
+// SetRateLimitFailFast makes requests exceeding a rate limit fail with ErrRateLimited instead of waiting for a token
+func (c *Client) SetRateLimitFailFast(val bool) {
+	limits := c.limits()
+	limits.mu.Lock()
+	defer limits.mu.Unlock()
+
+	limits.failFast = val
+}
+
+// SetAdaptiveRateLimit makes the Client honour the X-RateLimit-Remaining and X-RateLimit-Reset response headers by
+// holding back requests to a host until its window resets once it reports no remaining requests
+func (c *Client) SetAdaptiveRateLimit(val bool) {
+	limits := c.limits()
+	limits.mu.Lock()
+	defer limits.mu.Unlock()
+
+	limits.adaptive = val
+}
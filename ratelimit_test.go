@@ -0,0 +1,187 @@
+package requestor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	limiter := newRateLimiter(10, 2)
+	now := time.Now()
+
+	if wait := limiter.reserve(now); wait != 0 {
+		t.Errorf("Expected: %d \n Got: %d", 0, wait)
+	}
+
+	if wait := limiter.reserve(now); wait != 0 {
+		t.Errorf("Expected: %d \n Got: %d", 0, wait)
+	}
+
+	if wait := limiter.reserve(now); wait != 100*time.Millisecond {
+		t.Errorf("Expected: %s \n Got: %s", 100*time.Millisecond, wait)
+	}
+
+	if limiter.allow(now) {
+		t.Error("Expected no token to be available")
+	}
+
+	if !limiter.allow(now.Add(time.Second)) {
+		t.Error("Expected a token to be available after refill")
+	}
+}
+
+func TestRateLimiter_NoRate(t *testing.T) {
+	limiter := newRateLimiter(0, 1)
+	now := time.Now()
+
+	// Blocking and fail fast modes agree that a limiter without a rate lets everything through
+	for i := 0; i < 3; i++ {
+		if wait := limiter.reserve(now); wait != 0 {
+			t.Errorf("Expected: %d \n Got: %d", 0, wait)
+		}
+
+		if !limiter.allow(now) {
+			t.Error("Expected a limiter without a rate to allow every request")
+		}
+	}
+
+	client := New()
+	client.SetRateLimit(10, 1)
+	client.SetHostRateLimit("example.com", 10, 1)
+	client.SetRouteRateLimit("example.com/*", 10, 1)
+	client.SetRateLimit(0, 1)
+	client.SetHostRateLimit("example.com", -1, 1)
+	client.SetRouteRateLimit("example.com/*", 0, 1)
+
+	request, _ := http.NewRequest(http.MethodGet, "http://example.com/users", nil)
+	if limiters := client.rateLimits.limitersFor(request); len(limiters) != 0 {
+		t.Errorf("Expected: %d \n Got: %d", 0, len(limiters))
+	}
+}
+
+func TestClient_SetRateLimit(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetRateLimit(20, 1)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(testServer.URL, nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+	}
+
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected requests to be throttled, took %s", elapsed)
+	}
+}
+
+func TestClient_SetRateLimitFailFast(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetMaxRetries(3, 1)
+	client.SetHostRateLimit("127.0.0.1", 0.001, 1)
+	client.SetRateLimitFailFast(true)
+
+	resp, err := client.Get(testServer.URL, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	resp.Body.Close()
+
+	// Failing fast is not retried
+	start := time.Now()
+	_, err = client.Get(testServer.URL, nil, nil)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected: %v \n Got: %v", ErrRateLimited, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected to fail right away, took %s", elapsed)
+	}
+
+	// Neither is a cancelled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client = New()
+	client.SetMaxRetries(3, 1)
+	client.SetContext(ctx)
+
+	start = time.Now()
+	_, err = client.Get(testServer.URL, nil, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected: %v \n Got: %v", context.Canceled, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected to fail right away, took %s", elapsed)
+	}
+}
+
+func TestClient_SetRouteRateLimit_Context(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetRouteRateLimit("127.0.0.1/limited/*", 0.001, 1)
+
+	resp, err := client.Get(testServer.URL+"/limited/1", nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	resp.Body.Close()
+
+	resp, err = client.Get(testServer.URL+"/free", nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client.SetContext(ctx)
+
+	_, err = client.Get(testServer.URL+"/limited/2", nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected: %v \n Got: %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestClient_SetAdaptiveRateLimit(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("X-RateLimit-Remaining", "0")
+		writer.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetAdaptiveRateLimit(true)
+	client.SetRateLimitFailFast(true)
+
+	resp, err := client.Get(testServer.URL, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	resp.Body.Close()
+
+	_, err = client.Get(testServer.URL, nil, nil)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Expected: %v \n Got: %v", ErrRateLimited, err)
+	}
+}
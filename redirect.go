@@ -0,0 +1,91 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+// defaultMaxRedirects is how many redirects are followed unless told otherwise, as in net/http
+const defaultMaxRedirects = 10
+
+// ErrTooManyRedirects is returned when a call is redirected more times than the redirect policy allows
+var ErrTooManyRedirects = errors.New("too many redirects")
+
+// ErrRedirectBlocked is returned when the redirect policy forbids following a redirect to another host
+var ErrRedirectBlocked = errors.New("redirect to another host blocked")
+
+// RedirectPolicy tells a Client how to follow redirects. Redirects with 307 and 308 statuses always keep the method
+// and body of the request, other statuses switch to GET without a body as browsers do
+type RedirectPolicy struct {
+	// MaxRedirects is how many redirects are followed before failing with ErrTooManyRedirects, 0 means 10
+	MaxRedirects int
+	// Disable returns redirect responses to the caller instead of following them
+	Disable bool
+	// SameHostOnly fails with ErrRedirectBlocked instead of following redirects to another host
+	SameHostOnly bool
+	// StripAuthorization removes the Authorization header when redirected to another origin, meaning another scheme,
+	// host or port. net/http already removes it when redirected outside of the domain and its subdomains
+	StripAuthorization bool
+}
+
+// sameOrigin tells whether a and b have the same scheme, host and port
+func sameOrigin(a, b *url.URL) bool {
+	return a.Scheme == b.Scheme && a.Host == b.Host
+}
+
+// checkRedirect applies the redirect policy of the Client to request, via holding the requests made so far
+func (c *Client) checkRedirect(request *http.Request, via []*http.Request) error {
+	policy := c.redirectPolicy
+	if policy.Disable {
+		return http.ErrUseLastResponse
+	}
+
+	maxRedirects := policy.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+
+	if len(via) > maxRedirects {
+		return ErrTooManyRedirects
+	}
+
+	// Headers are copied from the first request on every hop, so it is the origin to compare with
+	if policy.SameHostOnly && request.URL.Hostname() != via[0].URL.Hostname() {
+		return ErrRedirectBlocked
+	}
+
+	if policy.StripAuthorization && !sameOrigin(request.URL, via[0].URL) {
+		request.Header.Del("Authorization")
+	}
+
+	chain := make([]*url.URL, 0, len(via))
+	for _, previous := range via {
+		chain = append(chain, previous.URL)
+	}
+	stateFromContext(request.Context()).setRedirects(chain)
+
+	return nil
+}
+
+// RedirectChain returns the URLs which redirected the call to response, in order, or nothing if it was not redirected
+func RedirectChain(response *http.Response) []*url.URL {
+	if response == nil || response.Request == nil {
+		return nil
+	}
+
+	state := stateFromContext(response.Request.Context())
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	chain := make([]*url.URL, len(state.redirects))
+	copy(chain, state.redirects)
+
+	return chain
+}
+
+// SetRedirectPolicy changes how the Client follows redirects
+func (c *Client) SetRedirectPolicy(policy RedirectPolicy) {
+	c.redirectPolicy = policy
+}
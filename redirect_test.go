@@ -0,0 +1,105 @@
+package requestor
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_SetRedirectPolicy(t *testing.T) {
+	otherServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("authorization=" + request.Header.Get("Authorization")))
+	}))
+	defer otherServer.Close()
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/a":
+			http.Redirect(writer, request, "/b", http.StatusFound)
+		case "/b":
+			http.Redirect(writer, request, "/c", http.StatusMovedPermanently)
+		case "/c":
+			writer.Write([]byte("done"))
+		case "/temporary":
+			http.Redirect(writer, request, "/echo", http.StatusTemporaryRedirect)
+		case "/echo":
+			body, _ := ioutil.ReadAll(request.Body)
+			writer.Write([]byte(request.Method + " " + string(body)))
+		case "/other":
+			http.Redirect(writer, request, otherServer.URL, http.StatusFound)
+		case "/localhost":
+			http.Redirect(writer, request, strings.Replace(otherServer.URL, "127.0.0.1", "localhost", 1), http.StatusFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client := New()
+
+	resp, err := client.Get(testServer.URL+"/a", nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	chain := RedirectChain(resp)
+	if body := readBody(t, resp); body != "done" || len(chain) != 2 || chain[0].Path != "/a" || chain[1].Path != "/b" {
+		t.Errorf("Expected /a then /b to redirect to done, got %v and %s", chain, body)
+	}
+
+	resp, err = client.Post(testServer.URL+"/temporary", map[string][]string{"Content-Type": {"application/json"}}, nil, map[string]string{"a": "b"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if body := readBody(t, resp); body != `POST {"a":"b"}` {
+		t.Errorf("Expected: %s \n Got: %s", `POST {"a":"b"}`, body)
+	}
+
+	headers := map[string][]string{"Authorization": {"Bearer token"}}
+	resp, err = client.Get(testServer.URL+"/other", headers, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if body := readBody(t, resp); body != "authorization=Bearer token" {
+		t.Errorf("Expected net/http to keep Authorization on the same domain, got %s", body)
+	}
+
+	client.SetRedirectPolicy(RedirectPolicy{StripAuthorization: true})
+	resp, err = client.Get(testServer.URL+"/other", headers, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if body := readBody(t, resp); body != "authorization=" {
+		t.Errorf("Expected Authorization to be stripped on another origin, got %s", body)
+	}
+
+	client.SetRedirectPolicy(RedirectPolicy{SameHostOnly: true})
+	if _, err := client.Get(testServer.URL+"/localhost", nil, nil); !errors.Is(err, ErrRedirectBlocked) {
+		t.Errorf("Expected: %v \n Got: %v", ErrRedirectBlocked, err)
+	}
+
+	client.SetRedirectPolicy(RedirectPolicy{MaxRedirects: 1})
+	if _, err := client.Get(testServer.URL+"/a", nil, nil); !errors.Is(err, ErrTooManyRedirects) {
+		t.Errorf("Expected: %v \n Got: %v", ErrTooManyRedirects, err)
+	}
+
+	client.SetRedirectPolicy(RedirectPolicy{Disable: true})
+	resp, err = client.Get(testServer.URL+"/a", nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	readBody(t, resp)
+
+	if resp.StatusCode != http.StatusFound || len(RedirectChain(resp)) != 0 {
+		t.Errorf("Expected the redirect to be returned as is, got %d", resp.StatusCode)
+	}
+}
@@ -3,12 +3,15 @@ package requestor
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -33,7 +36,7 @@ type Client struct {
 	IdleConnectionTimeout time.Duration
 	// MaxConnectionsPerHost specifies the max number of connection per host which include connections in dialing
 	// active and idle states. When exceeded request gets cancelled with net/http: request canceled.
-	// 0 means no limit
+	// 0 means no limit. See SetBulkhead to queue requests over a limit instead
 	MaxConnectionsPerHost int
 	// MaxIdleConnectionsPerHost specifies the max number of keep-alive connections per host
 	MaxIdleConnectionsPerHost int
@@ -45,8 +48,43 @@ type Client struct {
 	// TLSClientConfig specifies the TLS config to use
 	TLSClientConfig *tls.Config
 
-	transport  *http.Transport
-	httpClient *http.Client
+	ctx context.Context
+	// transportMu guards transport and the settings last applied to it, as requests configure it concurrently
+	transportMu     sync.Mutex
+	transport       *http.Transport
+	appliedSettings *transportSettings
+	// httpClient only holds the cookie jar, every call sends with its own http.Client built by newHTTPClient
+	httpClient    *http.Client
+	rateLimits    *rateLimits
+	bulkheads     *bulkheads
+	hedging       *hedging
+	endpoints     *EndpointPool
+	healthChecker *healthChecker
+	responseCache *responseCache
+	conditional   *responseCache
+	coalescer     *coalescer
+	requestLogger *requestLogger
+	tracer        Tracer
+	metrics       MetricsCollector
+	timing        bool
+	curlHook      func(command string)
+	curlOptions   CurlOptions
+	harRecorder   *HARRecorder
+	cassette      *Cassette
+	roundTripper  http.RoundTripper
+	faults        *faultInjector
+	headers       http.Header
+
+	redirectPolicy   RedirectPolicy
+	contentDigest    bool
+	progress         ProgressFunc
+	progressInterval time.Duration
+	decompression    bool
+	decoders         map[string]Decoder
+
+	compression          string
+	compressionThreshold int
+	encoders             map[string]Encoder
 }
 
 // New creates a new Client object
@@ -107,6 +145,53 @@ func (c *Client) SetIdleConnectionTimeout(timeout time.Duration) {
 	c.IdleConnectionTimeout = timeout
 }
 
+// SetContext sets the context used by requests made by this Client. Cancelling it aborts in-flight requests and
+// anything waiting on them, like rate limiters
+func (c *Client) SetContext(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// requestContext returns the context requests should be created with
+func (c *Client) requestContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+
+	return c.ctx
+}
+
+// SetRoundTripper replaces the network transport of the Client with roundTripper, for example a mock in tests. The
+// connection settings of the Client, like proxies, timeouts per connection and TLS config, no longer apply but every
+// other feature keeps working on top of it. A nil roundTripper goes back to the network
+func (c *Client) SetRoundTripper(roundTripper http.RoundTripper) {
+	c.roundTripper = roundTripper
+
+	if c.healthChecker != nil {
+		c.startHealthChecks()
+	}
+}
+
+// baseTransport returns the transport requests are finally sent with
+func (c *Client) baseTransport() http.RoundTripper {
+	if c.roundTripper != nil {
+		return c.roundTripper
+	}
+
+	return c.configuredTransport()
+}
+
+// Close stops the background work of the Client, like endpoint health checks, and closes its idle connections
+func (c *Client) Close() error {
+	c.stopHealthChecks()
+
+	c.transportMu.Lock()
+	defer c.transportMu.Unlock()
+
+	c.transport.CloseIdleConnections()
+
+	return nil
+}
+
 // Get performs a HTTP GET request. It takes in a URL, user specified headers, query params and returns Response and
 // error if exist
 func (c *Client) Get(url string, headers, queryParams map[string][]string) (response *http.Response, err error) {
@@ -164,47 +249,61 @@ func (c *Client) Trace(url string, headers, queryParams map[string][]string) (re
 
 // makeRequest is a helper method for the above HTTP methods
 func (c *Client) makeRequest(url, method string, headers, queryParams map[string][]string, data interface{}) (response *http.Response, err error) {
-	c.populateTransport()
+	httpClient := c.newHTTPClient()
 
-	// Converting headers to canonical headers
-	canonicalHeaders := make(map[string][]string, len(headers))
+	// Converting headers to canonical headers, on top of the default headers of the Client
+	canonicalHeaders := make(map[string][]string, len(c.headers)+len(headers))
+	for headerKey, headerValue := range c.headers {
+		canonicalHeaders[headerKey] = headerValue
+	}
 	for headerKey, headerValue := range headers {
 		canonicalHeaders[http.CanonicalHeaderKey(headerKey)] = headerValue
 	}
+	headers = canonicalHeaders
 
 	contentType, ok := canonicalHeaders["Content-Type"]
 
+	newRequest := c.newJSONRequest
+	if ok && len(contentType) >= 1 && !strings.Contains(contentType[0], "application/json") &&
+		strings.Contains(contentType[0], "application/x-www-form-urlencoded") {
+		newRequest = c.newFormURLEncodedRequest
+	}
+
+	state := &callState{}
+	ctx, span := c.startCallSpan(context.WithValue(c.requestContext(), callStateKey{}, state), method, url)
+	if span != nil {
+		defer func() {
+			setResponseAttributes(span, response, err)
+			span.End()
+		}()
+	}
+
 	for retry := 0; retry < int(c.MaxRetriesOnError); retry++ {
+		var request *http.Request
+		request, err = newRequest(url, method, headers, queryParams, data)
+		if err == nil {
+			request = request.WithContext(ctx)
+			state.nextAttempt()
 
-		if ok && len(contentType) >= 1 {
-			if contentType[0] == "application/json" || strings.Contains(contentType[0], "application/json") {
-				response, err = c.makeJSONRequest(url, method, headers, queryParams, data)
-				if err != nil {
-					time.Sleep(time.Duration(c.TimeBetweenRetries) * time.Second)
-					continue
-				}
-
-				break
-			}
-
-			if contentType[0] == "application/x-www-form-urlencoded" || strings.Contains(contentType[0], "application/x-www-form-urlencoded") {
-				response, err = c.makeFormURLEncodedRequest(url, method, headers, queryParams, data)
-				if err != nil {
-					time.Sleep(time.Duration(c.TimeBetweenRetries) * time.Second)
-					continue
-				}
-
-				break
-			}
+			response, err = c.send(httpClient, request)
 		}
 
-		response, err = c.makeJSONRequest(url, method, headers, queryParams, data)
-		if err != nil {
-			time.Sleep(time.Duration(c.TimeBetweenRetries) * time.Second)
-			continue
+		if err == nil {
+			break
 		}
 
-		break
+		// Retrying would fail the same way right away
+		if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrBulkheadFull) || ctx.Err() != nil {
+			return response, err
+		}
+
+		timer := time.NewTimer(time.Duration(c.TimeBetweenRetries) * time.Second)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return response, err
+		}
 	}
 
 	if err != nil {
@@ -218,10 +317,11 @@ func (c *Client) makeRequest(url, method string, headers, queryParams map[string
 	return response, nil
 }
 
-func (c *Client) makeFormURLEncodedRequest(formURL, method string, headers, queryParams map[string][]string, data interface{}) (response *http.Response, err error) {
+// newFormURLEncodedRequest builds a request with data encoded as application/x-www-form-urlencoded
+func (c *Client) newFormURLEncodedRequest(formURL, method string, headers, queryParams map[string][]string, data interface{}) (request *http.Request, err error) {
 	dataMap, ok := data.(map[string][]string)
 	if !ok && data != nil {
-		return response, errors.New("data should be of the form map[string][]string")
+		return request, errors.New("data should be of the form map[string][]string")
 	}
 
 	formData := url.Values{}
@@ -232,17 +332,13 @@ func (c *Client) makeFormURLEncodedRequest(formURL, method string, headers, quer
 		}
 	}
 
-	c.httpClient.Timeout = c.Timeout
-
-	var request *http.Request
-
 	if len(dataMap) > 0 {
-		request, err = http.NewRequest(method, formURL, strings.NewReader(formData.Encode()))
+		request, err = http.NewRequestWithContext(c.requestContext(), method, formURL, strings.NewReader(formData.Encode()))
 	} else {
-		request, err = http.NewRequest(method, formURL, nil)
+		request, err = http.NewRequestWithContext(c.requestContext(), method, formURL, nil)
 	}
 	if err != nil {
-		return response, err
+		return request, err
 	}
 
 	q := request.URL.Query()
@@ -261,30 +357,27 @@ func (c *Client) makeFormURLEncodedRequest(formURL, method string, headers, quer
 		}
 	}
 
-	return c.httpClient.Do(request)
+	return request, nil
 }
 
-func (c *Client) makeJSONRequest(url, method string, headers, queryParams map[string][]string, data interface{}) (response *http.Response, err error) {
+// newJSONRequest builds a request with data encoded as JSON
+func (c *Client) newJSONRequest(url, method string, headers, queryParams map[string][]string, data interface{}) (request *http.Request, err error) {
 	var dataBytes []byte
 
 	if data != nil {
 		dataBytes, err = json.Marshal(data)
 		if err != nil {
-			return response, err
+			return request, err
 		}
 	}
 
-	c.httpClient.Timeout = c.Timeout
-
-	var request *http.Request
-
 	if len(dataBytes) > 0 {
-		request, err = http.NewRequest(method, url, bytes.NewBuffer(dataBytes))
+		request, err = http.NewRequestWithContext(c.requestContext(), method, url, bytes.NewBuffer(dataBytes))
 	} else {
-		request, err = http.NewRequest(method, url, nil)
+		request, err = http.NewRequestWithContext(c.requestContext(), method, url, nil)
 	}
 	if err != nil {
-		return response, err
+		return request, err
 	}
 
 	q := request.URL.Query()
@@ -303,18 +396,284 @@ func (c *Client) makeJSONRequest(url, method string, headers, queryParams map[st
 		}
 	}
 
-	return c.httpClient.Do(request)
+	return request, nil
+}
+
+// send performs a single attempt of request
+func (c *Client) send(httpClient *http.Client, request *http.Request) (*http.Response, error) {
+	if c.hedging != nil && c.hedging.applies(request) {
+		return c.hedging.do(httpClient, request)
+	}
+
+	return httpClient.Do(request)
+}
+
+// transportSettings are the connection settings of the Client applied to its transport
+type transportSettings struct {
+	disableKeepAlives   bool
+	maxConnsPerHost     int
+	maxIdleConnsPerHost int
+	maxIdleConns        int
+	idleConnTimeout     time.Duration
+	tlsClientConfig     *tls.Config
+}
+
+// configuredTransport returns the transport of the Client with its current connection settings. A transport is
+// never changed once in use, new settings are applied to a copy which replaces it
+func (c *Client) configuredTransport() *http.Transport {
+	settings := transportSettings{
+		disableKeepAlives:   c.DisableKeepAlives,
+		maxConnsPerHost:     c.MaxConnectionsPerHost,
+		maxIdleConnsPerHost: c.MaxIdleConnectionsPerHost,
+		maxIdleConns:        c.MaxIdleConnections,
+		idleConnTimeout:     c.IdleConnectionTimeout,
+		tlsClientConfig:     c.TLSClientConfig,
+	}
+
+	c.transportMu.Lock()
+	defer c.transportMu.Unlock()
+
+	if c.appliedSettings != nil && *c.appliedSettings == settings {
+		return c.transport
+	}
+
+	if c.appliedSettings != nil {
+		previous := c.transport
+		c.transport = previous.Clone()
+		previous.CloseIdleConnections()
+	}
+
+	c.transport.DisableKeepAlives = settings.disableKeepAlives
+	c.transport.MaxConnsPerHost = settings.maxConnsPerHost
+	c.transport.IdleConnTimeout = settings.idleConnTimeout
+	c.transport.MaxIdleConnsPerHost = settings.maxIdleConnsPerHost
+	c.transport.MaxIdleConns = settings.maxIdleConns
+	c.transport.TLSClientConfig = settings.tlsClientConfig
+	c.appliedSettings = &settings
+
+	return c.transport
+}
+
+// setTransport replaces the transport of the Client, the connection settings are applied to it before its first use
+func (c *Client) setTransport(transport *http.Transport) {
+	c.transportMu.Lock()
+	defer c.transportMu.Unlock()
+
+	c.transport = transport
+	c.appliedSettings = nil
+}
+
+// newHTTPClient builds the http.Client of a call from the current settings of the Client, so calls running
+// concurrently never share mutable state
+func (c *Client) newHTTPClient() *http.Client {
+	return &http.Client{
+		Transport:     c.wrapTransport(c.baseTransport()),
+		CheckRedirect: c.checkRedirect,
+		Jar:           c.httpClient.Jar,
+		Timeout:       c.Timeout,
+	}
+}
+
+// wrapTransport layers the optional Client features around the base transport
+func (c *Client) wrapTransport(transport http.RoundTripper) http.RoundTripper {
+	if c.cassette != nil {
+		transport = &cassetteTransport{next: transport, cassette: c.cassette}
+	}
+
+	if c.faults != nil {
+		transport = &faultTransport{next: transport, injector: c.faults}
+	}
+
+	if c.contentDigest {
+		transport = &contentDigestTransport{next: transport}
+	}
+
+	if c.progress != nil {
+		transport = &progressTransport{next: transport, progress: c.progress, interval: c.progressInterval}
+	}
+
+	if c.compression != "" {
+		transport = &compressTransport{
+			next:      transport,
+			encoding:  c.compression,
+			encoder:   c.encoder(c.compression),
+			threshold: c.compressionThreshold,
+		}
+	}
+
+	// The curl command sits above compression so its body can be pasted in a shell, and below decompression so it
+	// shows the negotiated Accept-Encoding
+	if c.curlHook != nil {
+		transport = &curlTransport{next: transport, client: c, hook: c.curlHook, options: c.curlOptions}
+	}
+
+	if c.decompression {
+		transport = &decompressTransport{next: transport, decoders: c.decoders}
+	}
+
+	if c.timing {
+		transport = &timingTransport{next: transport}
+	}
+
+	if c.harRecorder != nil {
+		transport = &harTransport{next: transport, recorder: c.harRecorder}
+	}
+
+	if c.requestLogger != nil {
+		transport = &loggingTransport{next: transport, logger: c.requestLogger}
+	}
+
+	if c.metrics != nil {
+		transport = &metricsTransport{next: transport, collector: c.metrics}
+	}
+
+	if c.tracer != nil {
+		transport = &tracingTransport{next: transport, tracer: c.tracer}
+	}
+
+	if c.bulkheads != nil {
+		transport = &bulkheadTransport{next: transport, bulkheads: c.bulkheads}
+	}
+
+	if c.endpoints != nil {
+		transport = &endpointTransport{next: transport, pool: c.endpoints}
+	}
+
+	if c.rateLimits != nil {
+		transport = &rateLimitTransport{next: transport, limits: c.rateLimits}
+	}
+
+	if c.conditional != nil {
+		transport = &conditionalTransport{next: transport, store: c.conditional}
+	}
+
+	if c.coalescer != nil {
+		transport = &coalescingTransport{next: transport, coalescer: c.coalescer}
+	}
+
+	if c.responseCache != nil {
+		transport = &cacheTransport{next: transport, cache: c.responseCache}
+	}
+
+	return transport
+}
+
+// callState is shared by every attempt made for a single call to the Client, including retries and hedges
+type callState struct {
+	mu              sync.Mutex
+	attempt         int
+	failedEndpoints map[*Endpoint]bool
+	poolHost        string
+	fromCache       bool
+	revalidated     bool
+	timing          *timingRecorder
+	redirects       []*url.URL
+	contentEncoding string
+}
+
+// callStateKey is the context key under which the callState of a request is stored
+type callStateKey struct{}
+
+// stateFromContext returns the callState stored in ctx, or a fresh one for requests not made through makeRequest
+func stateFromContext(ctx context.Context) *callState {
+	if state, ok := ctx.Value(callStateKey{}).(*callState); ok {
+		return state
+	}
+
+	return &callState{}
+}
+
+// nextAttempt counts a new attempt of the call
+func (s *callState) nextAttempt() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempt++
+}
+
+// currentAttempt returns the number of the attempt in progress, starting at 1
+func (s *callState) currentAttempt() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.attempt == 0 {
+		return 1
+	}
+
+	return s.attempt
+}
+
+// setFromCache records whether the response of the call was served from the response cache
+func (s *callState) setFromCache(val bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fromCache = val
+}
+
+// setRevalidated records whether the response of the call is a stored response confirmed by a 304 Not Modified
+func (s *callState) setRevalidated(val bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.revalidated = val
+}
+
+// setRedirects records the URLs which redirected the call so far
+func (s *callState) setRedirects(redirects []*url.URL) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.redirects = redirects
+}
+
+// setContentEncoding records the Content-Encoding the response of the call was decompressed from
+func (s *callState) setContentEncoding(encoding string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.contentEncoding = encoding
+}
+
+// setTiming records the timing of the attempt whose response the call returns
+func (s *callState) setTiming(recorder *timingRecorder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.timing = recorder
+}
+
+// callbackBody wraps a response body and calls fn exactly once, as soon as the body is read to the end or closed
+type callbackBody struct {
+	io.ReadCloser
+	once sync.Once
+	fn   func()
+}
+
+// newCallbackBody wraps body so fn is called once the caller is done with it
+func newCallbackBody(body io.ReadCloser, fn func()) io.ReadCloser {
+	if body == nil {
+		fn()
+		return nil
+	}
+
+	return &callbackBody{ReadCloser: body, fn: fn}
+}
+
+// Read implements io.Reader
+func (b *callbackBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.fn)
+	}
+
+	return n, err
 }
 
-// populateTransport populates the transport config
-func (c *Client) populateTransport() {
-	c.transport.DisableKeepAlives = c.DisableKeepAlives
-	c.transport.MaxConnsPerHost = c.MaxConnectionsPerHost
-	c.transport.IdleConnTimeout = c.IdleConnectionTimeout
-	c.transport.MaxConnsPerHost = c.MaxConnectionsPerHost
-	c.transport.MaxIdleConnsPerHost = c.MaxIdleConnectionsPerHost
-	c.transport.MaxIdleConns = c.MaxIdleConnections
-	c.transport.TLSClientConfig = c.TLSClientConfig
+// Close implements io.Closer
+func (b *callbackBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.fn)
 
-	c.httpClient.Transport = c.transport
+	return err
 }
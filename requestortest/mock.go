@@ -0,0 +1,319 @@
+// Package requestortest contains a mock transport to unit test code using a requestor Client without a server
+package requestortest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrUnexpectedRequest is returned for requests matching no expectation
+var ErrUnexpectedRequest = errors.New("unexpected request")
+
+// TestingT is the part of *testing.T used by the assertions
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Expectation is a request the MockTransport expects along with the response it answers with. Build it with the
+// With and Respond methods, which return the Expectation so calls can be chained
+type Expectation struct {
+	method     string
+	urlPattern string
+	headers    http.Header
+	query      map[string]string
+	body       []func(body []byte) bool
+
+	status         int
+	responseHeader http.Header
+	responseBody   []byte
+	err            error
+	times          int
+
+	calls int
+}
+
+// WithHeader only matches requests with header set to value
+func (e *Expectation) WithHeader(header, value string) *Expectation {
+	e.headers.Add(header, value)
+	return e
+}
+
+// WithQuery only matches requests with the query param set to value
+func (e *Expectation) WithQuery(param, value string) *Expectation {
+	e.query[param] = value
+	return e
+}
+
+// WithBody only matches requests whose body satisfies matcher
+func (e *Expectation) WithBody(matcher func(body []byte) bool) *Expectation {
+	e.body = append(e.body, matcher)
+	return e
+}
+
+// WithJSONBody only matches requests whose body is the JSON encoding of v, regardless of formatting and key order
+func (e *Expectation) WithJSONBody(v interface{}) *Expectation {
+	return e.WithBody(func(body []byte) bool {
+		expected, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+
+		var expectedValue, value interface{}
+		if json.Unmarshal(expected, &expectedValue) != nil || json.Unmarshal(body, &value) != nil {
+			return false
+		}
+
+		return reflect.DeepEqual(expectedValue, value)
+	})
+}
+
+// Respond answers matching requests with status and body
+func (e *Expectation) Respond(status int, body string) *Expectation {
+	e.status = status
+	e.responseBody = []byte(body)
+	e.err = nil
+	return e
+}
+
+// RespondJSON answers matching requests with status and the JSON encoding of v
+func (e *Expectation) RespondJSON(status int, v interface{}) *Expectation {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+
+	e.responseHeader.Set("Content-Type", "application/json")
+	return e.Respond(status, string(body))
+}
+
+// RespondHeader adds a header to the responses
+func (e *Expectation) RespondHeader(header, value string) *Expectation {
+	e.responseHeader.Add(header, value)
+	return e
+}
+
+// RespondError fails matching requests with err, as if the network failed
+func (e *Expectation) RespondError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Times makes the expectation match exactly n requests, requests after that fall through to the next expectations.
+// By default an expectation matches any number of requests and must match at least one
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// Once is a shortcut for Times(1)
+func (e *Expectation) Once() *Expectation {
+	return e.Times(1)
+}
+
+// matches tells whether request, whose body is body, matches the expectation
+func (e *Expectation) matches(request *http.Request, body []byte) bool {
+	if e.times > 0 && e.calls >= e.times {
+		return false
+	}
+
+	if e.method != "" && e.method != request.Method {
+		return false
+	}
+
+	requestURL := *request.URL
+	requestURL.RawQuery = ""
+	if matched, err := path.Match(e.urlPattern, requestURL.String()); err != nil || !matched {
+		return false
+	}
+
+	for header, values := range e.headers {
+		for _, value := range values {
+			if !contains(request.Header.Values(header), value) {
+				return false
+			}
+		}
+	}
+
+	query := request.URL.Query()
+	for param, value := range e.query {
+		if !contains(query[param], value) {
+			return false
+		}
+	}
+
+	for _, matcher := range e.body {
+		if !matcher(body) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// contains tells whether values holds value
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String describes the expectation in failure messages
+func (e *Expectation) String() string {
+	method := e.method
+	if method == "" {
+		method = "*"
+	}
+
+	return method + " " + e.urlPattern
+}
+
+// MockTransport is an http.RoundTripper answering requests from a list of expectations, install it with
+// Client.SetRoundTripper
+type MockTransport struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+	requests     []*http.Request
+	unexpected   []string
+}
+
+// NewMockTransport creates a MockTransport without expectations
+func NewMockTransport() *MockTransport {
+	return &MockTransport{}
+}
+
+// Expect registers an expectation for requests with method, or any method if empty, whose URL without the query
+// matches urlPattern as understood by path.Match, for example https://api.example.com/users/*. Expectations are
+// tried in the order they are registered and answer with 200 OK and no body unless told otherwise
+func (m *MockTransport) Expect(method, urlPattern string) *Expectation {
+	expectation := &Expectation{
+		method:         method,
+		urlPattern:     urlPattern,
+		headers:        make(http.Header),
+		query:          make(map[string]string),
+		status:         http.StatusOK,
+		responseHeader: make(http.Header),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expectations = append(m.expectations, expectation)
+
+	return expectation
+}
+
+// RoundTrip implements http.RoundTripper
+func (m *MockTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	var body []byte
+	if request.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(request.Body)
+		request.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, request)
+
+	for _, expectation := range m.expectations {
+		if !expectation.matches(request, body) {
+			continue
+		}
+		expectation.calls++
+
+		if expectation.err != nil {
+			return nil, expectation.err
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", expectation.status, http.StatusText(expectation.status)),
+			StatusCode:    expectation.status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        expectation.responseHeader.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader(expectation.responseBody)),
+			ContentLength: int64(len(expectation.responseBody)),
+			Request:       request,
+		}, nil
+	}
+
+	m.unexpected = append(m.unexpected, request.Method+" "+request.URL.String())
+
+	return nil, fmt.Errorf("%w: %s %s", ErrUnexpectedRequest, request.Method, request.URL)
+}
+
+// Requests returns every request received so far, in order
+func (m *MockTransport) Requests() []*http.Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requests := make([]*http.Request, len(m.requests))
+	copy(requests, m.requests)
+
+	return requests
+}
+
+// Calls returns how many requests matched expectation
+func (m *MockTransport) Calls(expectation *Expectation) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return expectation.calls
+}
+
+// AssertExpectations fails t if an expectation was not met, or if a request matched no expectation
+func (m *MockTransport) AssertExpectations(t TestingT) bool {
+	t.Helper()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+	for _, expectation := range m.expectations {
+		switch {
+		case expectation.times > 0 && expectation.calls != expectation.times:
+			t.Errorf("Expected %s to be called %d times, got %d", expectation, expectation.times, expectation.calls)
+			ok = false
+		case expectation.times == 0 && expectation.calls == 0:
+			t.Errorf("Expected %s to be called", expectation)
+			ok = false
+		}
+	}
+
+	if len(m.unexpected) > 0 {
+		t.Errorf("Unexpected requests: %s", strings.Join(m.unexpected, ", "))
+		ok = false
+	}
+
+	return ok
+}
+
+// AssertNumberOfCalls fails t unless exactly n requests matched expectation
+func (m *MockTransport) AssertNumberOfCalls(t TestingT, expectation *Expectation, n int) bool {
+	t.Helper()
+
+	if calls := m.Calls(expectation); calls != n {
+		t.Errorf("Expected %s to be called %d times, got %d", expectation, n, calls)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,93 @@
+package requestortest
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	requestor "github.com/flannel-dev-lab/Requestor"
+)
+
+type recordingT struct {
+	errors []string
+}
+
+func (r *recordingT) Helper() {}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockTransport(t *testing.T) {
+	mock := NewMockTransport()
+	createUser := mock.Expect(http.MethodPost, "https://api.example.com/users").
+		WithHeader("Content-Type", "application/json").
+		WithJSONBody(map[string]string{"name": "gopher"}).
+		RespondJSON(http.StatusCreated, map[string]int{"id": 1}).
+		Once()
+	getUser := mock.Expect(http.MethodGet, "https://api.example.com/users/*").
+		WithQuery("fields", "name").
+		Respond(http.StatusOK, "gopher")
+
+	client := requestor.New()
+	client.SetRoundTripper(mock)
+
+	resp, err := client.Post("https://api.example.com/users", map[string][]string{"Content-Type": {"application/json"}}, nil, map[string]string{"name": "gopher"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated || string(body) != `{"id":1}` {
+		t.Errorf("Expected: %d %s \n Got: %d %s", http.StatusCreated, `{"id":1}`, resp.StatusCode, body)
+	}
+
+	for _, id := range []string{"1", "2"} {
+		resp, err := client.Get("https://api.example.com/users/"+id, nil, map[string][]string{"fields": {"name"}})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+	}
+
+	mock.AssertExpectations(t)
+	mock.AssertNumberOfCalls(t, createUser, 1)
+	mock.AssertNumberOfCalls(t, getUser, 2)
+
+	if requests := mock.Requests(); len(requests) != 3 {
+		t.Errorf("Expected: %d \n Got: %d", 3, len(requests))
+	}
+}
+
+func TestMockTransport_Failures(t *testing.T) {
+	networkDown := errors.New("network down")
+
+	mock := NewMockTransport()
+	mock.Expect(http.MethodGet, "https://api.example.com/flaky").RespondError(networkDown).Once()
+	mock.Expect("", "https://api.example.com/never")
+
+	client := requestor.New()
+	client.SetRoundTripper(mock)
+
+	if _, err := client.Get("https://api.example.com/flaky", nil, nil); !errors.Is(err, networkDown) {
+		t.Errorf("Expected: %v \n Got: %v", networkDown, err)
+	}
+
+	if _, err := client.Get("https://api.example.com/flaky", nil, nil); !errors.Is(err, ErrUnexpectedRequest) {
+		t.Errorf("Expected: %v \n Got: %v", ErrUnexpectedRequest, err)
+	}
+
+	recorder := &recordingT{}
+	if mock.AssertExpectations(recorder) {
+		t.Error("Expected assertions to fail")
+	}
+
+	if len(recorder.errors) != 2 {
+		t.Errorf("Expected the missing call and the unexpected request to be reported, got %v", recorder.errors)
+	}
+}
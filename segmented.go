@@ -0,0 +1,170 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// errRangesUnsupported is returned by segmented downloads when the server does not honor Range requests
+var errRangesUnsupported = errors.New("server does not support range requests")
+
+// errSegmentAborted stops the segments of a download once one of them failed
+var errSegmentAborted = errors.New("download aborted")
+
+// segment is a byte range of a segmented download
+type segment struct {
+	client    *Client
+	url       string
+	part      *os.File
+	validator string
+	aborted   *int32
+
+	// offset is the next byte to download and end the last one, both inclusive
+	offset int64
+	end    int64
+}
+
+// Write implements io.Writer, writing to the region of the segment in the partial file shared by every segment
+func (s *segment) Write(p []byte) (int, error) {
+	if atomic.LoadInt32(s.aborted) == 1 {
+		return 0, errSegmentAborted
+	}
+
+	n, err := s.part.WriteAt(p, s.offset)
+	atomic.AddInt64(&s.offset, int64(n))
+
+	return n, err
+}
+
+// progress returns the next byte to download
+func (s *segment) progress() int64 {
+	return atomic.LoadInt64(&s.offset)
+}
+
+// attempt requests the rest of the segment and writes it to its region of the partial file
+func (s *segment) attempt() (done bool, retry bool, err error) {
+	if atomic.LoadInt32(s.aborted) == 1 {
+		return false, false, errSegmentAborted
+	}
+
+	headers := downloadHeaders()
+	headers["Range"] = []string{"bytes=" + strconv.FormatInt(s.progress(), 10) + "-" + strconv.FormatInt(s.end, 10)}
+	if s.validator != "" {
+		headers["If-Range"] = []string{s.validator}
+	}
+
+	response, err := s.client.makeRequest(s.url, http.MethodGet, headers, nil, nil)
+	if err != nil {
+		return false, false, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusOK {
+		return false, false, errRangesUnsupported
+	}
+
+	if response.StatusCode != http.StatusPartialContent {
+		return false, false, errors.New("unexpected status " + response.Status)
+	}
+
+	if start, _ := contentRange(response.Header.Get("Content-Range")); start != s.progress() {
+		return false, false, errors.New("server resumed the download at the wrong offset")
+	}
+
+	if _, err := io.Copy(s, response.Body); err != nil {
+		return false, err != errSegmentAborted, err
+	}
+
+	if s.progress() != s.end+1 {
+		return false, false, ErrContentLengthMismatch
+	}
+
+	return true, false, nil
+}
+
+// downloadSegments downloads rawURL to part as concurrent byte ranges. It returns errRangesUnsupported, with part
+// emptied, when the server does not honor ranges so the caller can fall back to a single stream
+func (c *Client) downloadSegments(rawURL string, part *os.File, segments int, observe func(response *http.Response)) error {
+	headers := downloadHeaders()
+	headers["Range"] = []string{"bytes=0-0"}
+
+	probe, err := c.makeRequest(rawURL, http.MethodGet, headers, nil, nil)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(probe.Body, 1))
+	probe.Body.Close()
+
+	if observe != nil && probe.StatusCode == http.StatusPartialContent {
+		observe(probe)
+	}
+
+	_, total := contentRange(probe.Header.Get("Content-Range"))
+	if probe.StatusCode != http.StatusPartialContent || total <= 0 {
+		if err := part.Truncate(0); err != nil {
+			return err
+		}
+
+		return errRangesUnsupported
+	}
+
+	if err := part.Truncate(total); err != nil {
+		return err
+	}
+
+	if int64(segments) > total {
+		segments = int(total)
+	}
+
+	var aborted int32
+	size := total / int64(segments)
+	errs := make([]error, segments)
+
+	var wg sync.WaitGroup
+	for i := 0; i < segments; i++ {
+		s := &segment{
+			client:    c,
+			url:       rawURL,
+			part:      part,
+			validator: validator(probe),
+			aborted:   &aborted,
+			offset:    int64(i) * size,
+			end:       int64(i+1)*size - 1,
+		}
+		if i == segments-1 {
+			s.end = total - 1
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			if errs[i] = c.resume(s.attempt, s.progress); errs[i] != nil {
+				atomic.StoreInt32(&aborted, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// The first real failure is reported rather than the aborts it caused in the other segments
+	for _, err := range errs {
+		if err != nil && err != errSegmentAborted {
+			if err == errRangesUnsupported {
+				if truncateErr := part.Truncate(0); truncateErr != nil {
+					return truncateErr
+				}
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package requestor
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClient_DownloadWithOptions_Segments(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghij"), 10001)
+
+	var mu sync.Mutex
+	ranges := make(map[string]bool)
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		mu.Lock()
+		ranges[request.Header.Get("Range")] = true
+		mu.Unlock()
+
+		writer.Header().Set("ETag", `"v1"`)
+		http.ServeContent(writer, request, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer testServer.Close()
+
+	dir, err := ioutil.TempDir("", "segmented")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "artifact.bin")
+
+	if err := New().DownloadWithOptions(testServer.URL, path, DownloadOptions{Segments: 4}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if downloaded, _ := ioutil.ReadFile(path); !bytes.Equal(downloaded, content) {
+		t.Errorf("Expected: %d bytes \n Got: %d bytes", len(content), len(downloaded))
+	}
+
+	for _, expected := range []string{"bytes=0-0", "bytes=0-25001", "bytes=25002-50003", "bytes=50004-75005", "bytes=75006-100009"} {
+		if !ranges[expected] {
+			t.Errorf("Expected a request for %s, got %v", expected, ranges)
+		}
+	}
+}
+
+func TestClient_DownloadWithOptions_NoRanges(t *testing.T) {
+	content := bytes.Repeat([]byte("abcdefghij"), 1000)
+
+	requests := 0
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		requests++
+		writer.Write(content)
+	}))
+	defer testServer.Close()
+
+	dir, err := ioutil.TempDir("", "segmented")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "artifact.bin")
+
+	if err := New().DownloadWithOptions(testServer.URL, path, DownloadOptions{Segments: 4}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if downloaded, _ := ioutil.ReadFile(path); !bytes.Equal(downloaded, content) {
+		t.Errorf("Expected: %d bytes \n Got: %d bytes", len(content), len(downloaded))
+	}
+
+	if requests != 2 {
+		t.Errorf("Expected a probe then a single stream, got %d requests", requests)
+	}
+}
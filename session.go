@@ -0,0 +1,224 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionOptions configures a Session
+type SessionOptions struct {
+	// PublicSuffixList keeps servers from setting cookies for a whole public suffix like co.uk. Nil uses a small
+	// built-in list of the most common suffixes, pass golang.org/x/net/publicsuffix.List to cover all of them
+	PublicSuffixList cookiejar.PublicSuffixList
+	// CookieFile persists cookies between runs, they are loaded from it when the Session is created and written to it
+	// by Save. Empty means cookies only live in memory
+	CookieFile string
+}
+
+// commonPublicSuffixes are the public suffixes of more than one label known to the built-in list, every other domain
+// has its last label as public suffix
+var commonPublicSuffixes = map[string]bool{
+	"ac.uk": true, "co.uk": true, "gov.uk": true, "ltd.uk": true, "me.uk": true, "net.uk": true, "org.uk": true,
+	"plc.uk": true, "sch.uk": true, "com.au": true, "edu.au": true, "gov.au": true, "net.au": true, "org.au": true,
+	"co.nz": true, "net.nz": true, "org.nz": true, "ac.jp": true, "co.jp": true, "go.jp": true, "ne.jp": true,
+	"or.jp": true, "co.kr": true, "or.kr": true, "com.br": true, "net.br": true, "org.br": true, "com.cn": true,
+	"net.cn": true, "org.cn": true, "gov.cn": true, "co.in": true, "net.in": true, "org.in": true, "co.za": true,
+	"org.za": true, "com.mx": true, "com.tr": true, "com.tw": true, "com.hk": true, "com.sg": true, "com.ar": true,
+	"github.io": true, "gitlab.io": true, "herokuapp.com": true, "appspot.com": true, "blogspot.com": true,
+	"cloudfront.net": true, "azurewebsites.net": true, "netlify.app": true, "vercel.app": true, "pages.dev": true,
+	"workers.dev": true,
+}
+
+// commonPublicSuffixList is the cookiejar.PublicSuffixList used when SessionOptions has none
+type commonPublicSuffixList struct{}
+
+// PublicSuffix implements cookiejar.PublicSuffixList
+func (commonPublicSuffixList) PublicSuffix(domain string) string {
+	domain = strings.ToLower(domain)
+	for suffix := domain; strings.Contains(suffix, "."); suffix = suffix[strings.Index(suffix, ".")+1:] {
+		if commonPublicSuffixes[suffix] {
+			return suffix
+		}
+	}
+
+	return domain[strings.LastIndex(domain, ".")+1:]
+}
+
+// String implements cookiejar.PublicSuffixList
+func (commonPublicSuffixList) String() string {
+	return "requestor common public suffixes"
+}
+
+// persistedCookie is a cookie along with the URL which set it, as stored in a cookie file
+type persistedCookie struct {
+	URL    string       `json:"url"`
+	Cookie *http.Cookie `json:"cookie"`
+}
+
+// persistentJar is a cookie jar which remembers the cookies it was given so they can be saved, as cookiejar.Jar
+// does not expose them
+type persistentJar struct {
+	jar *cookiejar.Jar
+
+	mu      sync.Mutex
+	cookies map[string]persistedCookie
+}
+
+// SetCookies implements http.CookieJar
+func (j *persistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	origin := url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}
+	for _, cookie := range cookies {
+		stored := *cookie
+		key := u.Hostname() + ";" + stored.Domain + ";" + stored.Path + ";" + stored.Name
+
+		// Max-Age is relative to when the cookie was received, it is turned into an absolute expiry to survive restarts
+		if stored.MaxAge > 0 {
+			stored.Expires = time.Now().Add(time.Duration(stored.MaxAge) * time.Second)
+			stored.MaxAge = 0
+		}
+
+		if stored.MaxAge < 0 || (!stored.Expires.IsZero() && stored.Expires.Before(time.Now())) {
+			delete(j.cookies, key)
+			continue
+		}
+
+		j.cookies[key] = persistedCookie{URL: origin.String(), Cookie: &stored}
+	}
+}
+
+// Cookies implements http.CookieJar
+func (j *persistentJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// Session is a Client keeping cookies and default headers across calls, so login flows work as in a browser
+type Session struct {
+	*Client
+	jar        *persistentJar
+	cookieFile string
+}
+
+// NewSession creates a Session, loading its cookies from options.CookieFile when the file exists
+func NewSession(options SessionOptions) (*Session, error) {
+	if options.PublicSuffixList == nil {
+		options.PublicSuffixList = commonPublicSuffixList{}
+	}
+
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: options.PublicSuffixList})
+	if err != nil {
+		return nil, err
+	}
+
+	session := &Session{
+		Client:     New(),
+		jar:        &persistentJar{jar: jar, cookies: make(map[string]persistedCookie)},
+		cookieFile: options.CookieFile,
+	}
+	session.httpClient.Jar = session.jar
+
+	if options.CookieFile != "" {
+		if err := session.load(); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return session, nil
+}
+
+// load adds the cookies of the cookie file to the jar
+func (s *Session) load() error {
+	data, err := ioutil.ReadFile(s.cookieFile)
+	if err != nil {
+		return err
+	}
+
+	var cookies []persistedCookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return err
+	}
+
+	for _, cookie := range cookies {
+		origin, err := url.Parse(cookie.URL)
+		if err != nil || cookie.Cookie == nil {
+			continue
+		}
+
+		s.jar.SetCookies(origin, []*http.Cookie{cookie.Cookie})
+	}
+
+	return nil
+}
+
+// Save writes the cookies of the Session to its cookie file, expired cookies are dropped
+func (s *Session) Save() error {
+	if s.cookieFile == "" {
+		return nil
+	}
+
+	s.jar.mu.Lock()
+	cookies := make([]persistedCookie, 0, len(s.jar.cookies))
+	for key, cookie := range s.jar.cookies {
+		if !cookie.Cookie.Expires.IsZero() && cookie.Cookie.Expires.Before(time.Now()) {
+			delete(s.jar.cookies, key)
+			continue
+		}
+		cookies = append(cookies, cookie)
+	}
+	s.jar.mu.Unlock()
+
+	data, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.cookieFile, data)
+}
+
+// Cookies returns the cookies the Session sends to rawURL
+func (s *Session) Cookies(rawURL string) ([]*http.Cookie, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.jar.Cookies(u), nil
+}
+
+// SetCookies adds cookies to the Session as if rawURL had set them
+func (s *Session) SetCookies(rawURL string, cookies ...*http.Cookie) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	s.jar.SetCookies(u, cookies)
+
+	return nil
+}
+
+// SetHeader sets a header sent with every call of the Session, headers passed to a call take precedence
+func (s *Session) SetHeader(key, value string) {
+	if s.headers == nil {
+		s.headers = make(http.Header)
+	}
+
+	s.headers.Set(key, value)
+}
+
+// DelHeader removes a header set with SetHeader
+func (s *Session) DelHeader(key string) {
+	s.headers.Del(key)
+}
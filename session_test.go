@@ -0,0 +1,136 @@
+package requestor
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		switch request.URL.Path {
+		case "/login":
+			http.SetCookie(writer, &http.Cookie{Name: "session", Value: "abc", Path: "/", MaxAge: 3600})
+			http.SetCookie(writer, &http.Cookie{Name: "flash", Value: "hi", Path: "/"})
+			http.Redirect(writer, request, "/home", http.StatusFound)
+		case "/home":
+			cookie, err := request.Cookie("session")
+			if err != nil || cookie.Value != "abc" {
+				writer.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			writer.Write([]byte(request.Header.Get("X-Client") + " " + request.Header.Get("Accept")))
+		}
+	}))
+	defer testServer.Close()
+
+	dir, err := ioutil.TempDir("", "session")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	cookieFile := filepath.Join(dir, "cookies.json")
+
+	session, err := NewSession(SessionOptions{CookieFile: cookieFile})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	session.SetHeader("X-Client", "requestor")
+	session.SetHeader("Accept", "text/plain")
+
+	resp, err := session.Post(testServer.URL+"/login", map[string][]string{"accept": {"text/html"}}, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if body := readBody(t, resp); resp.StatusCode != http.StatusOK || body != "requestor text/html" {
+		t.Errorf("Expected: %d %s \n Got: %d %s", http.StatusOK, "requestor text/html", resp.StatusCode, body)
+	}
+
+	if err := session.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	restored, err := NewSession(SessionOptions{CookieFile: cookieFile})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	cookies, err := restored.Cookies(testServer.URL)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if len(cookies) != 2 {
+		t.Errorf("Expected: %d \n Got: %d", 2, len(cookies))
+	}
+
+	resp, err = restored.Get(testServer.URL+"/home", nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	readBody(t, resp)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected: %d \n Got: %d", http.StatusOK, resp.StatusCode)
+	}
+}
+
+func TestSession_SetCookies(t *testing.T) {
+	session, err := NewSession(SessionOptions{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if err := session.SetCookies("https://example.com/", &http.Cookie{Name: "gone", Value: "x", MaxAge: -1}, &http.Cookie{Name: "kept", Value: "y"}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	cookies, _ := session.Cookies("https://example.com/")
+	if len(cookies) != 1 || cookies[0].Name != "kept" {
+		t.Errorf("Expected only the live cookie, got %v", cookies)
+	}
+
+	if err := session.Save(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSession_PublicSuffix(t *testing.T) {
+	session, err := NewSession(SessionOptions{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	session.SetCookies("https://shop.example.co.uk/", &http.Cookie{Name: "tracker", Value: "x", Domain: "co.uk"},
+		&http.Cookie{Name: "cart", Value: "y", Domain: "example.co.uk"})
+
+	cookies, _ := session.Cookies("https://other.co.uk/")
+	if len(cookies) != 0 {
+		t.Errorf("Expected: %d \n Got: %d cookies", 0, len(cookies))
+	}
+
+	cookies, _ = session.Cookies("https://www.example.co.uk/")
+	if len(cookies) != 1 || cookies[0].Name != "cart" {
+		t.Errorf("Expected only the cookie of the site, got %v", cookies)
+	}
+
+	for domain, expected := range map[string]string{"www.example.co.uk": "co.uk", "example.com": "com", "localhost": "localhost"} {
+		if suffix := (commonPublicSuffixList{}).PublicSuffix(domain); suffix != expected {
+			t.Errorf("Expected: %s \n Got: %s", expected, suffix)
+		}
+	}
+}
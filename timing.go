@@ -0,0 +1,162 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing is the breakdown of the time spent on a request sent over the network
+type Timing struct {
+	// DNS is the time spent resolving the host, 0 when the connection was reused or the host is an IP address
+	DNS time.Duration
+	// Connect is the time spent opening the TCP connection, 0 when the connection was reused
+	Connect time.Duration
+	// TLS is the time spent on the TLS handshake, 0 for plain HTTP or when the connection was reused
+	TLS time.Duration
+	// TimeToFirstByte runs from sending the request until the first byte of the response arrives
+	TimeToFirstByte time.Duration
+	// Transfer runs from the first byte of the response until its body is read to the end or closed, 0 until then
+	Transfer time.Duration
+	// Total runs from sending the request until its body is read to the end or closed, 0 until then
+	Total            time.Duration
+	ConnectionReused bool
+}
+
+// timingRecorder fills a Timing from the httptrace hooks of a request, which may run on other goroutines
+type timingRecorder struct {
+	mu                            sync.Mutex
+	timing                        Timing
+	start, dnsStart, connectStart time.Time
+	tlsStart, firstByte           time.Time
+}
+
+// trace returns the hooks recording the timing of a request
+func (r *timingRecorder) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			r.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			r.timing.DNS = time.Since(r.dnsStart)
+		},
+		ConnectStart: func(network, addr string) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			if r.connectStart.IsZero() {
+				r.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			if err == nil {
+				r.timing.Connect = time.Since(r.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			r.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			r.timing.TLS = time.Since(r.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			r.timing.ConnectionReused = info.Reused
+		},
+		GotFirstResponseByte: func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			r.firstByte = time.Now()
+			r.timing.TimeToFirstByte = r.firstByte.Sub(r.start)
+		},
+	}
+}
+
+// done records the end of the transfer of the response body
+func (r *timingRecorder) done() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if !r.firstByte.IsZero() {
+		r.timing.Transfer = now.Sub(r.firstByte)
+	}
+	r.timing.Total = now.Sub(r.start)
+}
+
+// get returns a copy of the Timing recorded so far
+func (r *timingRecorder) get() Timing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.timing
+}
+
+// timingTransport records the Timing of every request sent over the network
+type timingTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *timingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	recorder := &timingRecorder{start: time.Now()}
+	request = request.WithContext(httptrace.WithClientTrace(request.Context(), recorder.trace()))
+
+	response, err := t.next.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only attempts which got a response are recorded, so the call keeps the timing of the response it returns
+	stateFromContext(request.Context()).setTiming(recorder)
+	response.Body = newCallbackBody(response.Body, recorder.done)
+
+	return response, nil
+}
+
+// TimingOf returns the Timing of the last request sent over the network to get response. It is only available when
+// timing is enabled with SetTiming and the response did not come from the cache, Transfer and Total are set once the
+// body is read to the end or closed
+func TimingOf(response *http.Response) (Timing, bool) {
+	if response == nil || response.Request == nil {
+		return Timing{}, false
+	}
+
+	state := stateFromContext(response.Request.Context())
+	state.mu.Lock()
+	recorder := state.timing
+	state.mu.Unlock()
+
+	if recorder == nil {
+		return Timing{}, false
+	}
+
+	return recorder.get(), true
+}
+
+// SetTiming enables recording the DNS, connect, TLS, time to first byte and transfer times of requests, read them
+// with TimingOf
+func (c *Client) SetTiming(val bool) {
+	c.timing = val
+}
@@ -0,0 +1,75 @@
+package requestor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_SetTiming(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("hello"))
+	}))
+	defer testServer.Close()
+
+	client := New()
+	client.DisableKeepAlive(false)
+	client.SetTLSClientConfig(testServer.Client().Transport.(*http.Transport).TLSClientConfig)
+	client.SetTiming(true)
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(testServer.URL, nil, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if timing, ok := TimingOf(resp); !ok || timing.Total != 0 {
+			t.Errorf("Expected timing without a total before the body is read, got %+v", timing)
+		}
+		readBody(t, resp)
+
+		timing, ok := TimingOf(resp)
+		if !ok {
+			t.Error("Expected timing to be recorded")
+			return
+		}
+
+		if timing.TimeToFirstByte <= 0 || timing.Total < timing.TimeToFirstByte {
+			t.Errorf("Expected time to first byte within the total, got %+v", timing)
+		}
+
+		reused := i == 1
+		if timing.ConnectionReused != reused {
+			t.Errorf("Expected: %t \n Got: %t", reused, timing.ConnectionReused)
+		}
+
+		if !reused && (timing.Connect <= 0 || timing.TLS <= 0) {
+			t.Errorf("Expected connect and TLS times on a new connection, got %+v", timing)
+		}
+
+		if reused && (timing.Connect != 0 || timing.TLS != 0) {
+			t.Errorf("Expected no connect or TLS time on a reused connection, got %+v", timing)
+		}
+	}
+}
+
+func TestClient_SetTiming_Disabled(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer testServer.Close()
+
+	client := New()
+	client.SetTimeout(time.Second)
+
+	resp, err := client.Get(testServer.URL, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	readBody(t, resp)
+
+	if _, ok := TimingOf(resp); ok {
+		t.Error("Expected no timing when it is disabled")
+	}
+}
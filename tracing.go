@@ -0,0 +1,325 @@
+// Package requestor contains the methods to make HTTP requests to different endpoints
+package requestor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SpanContext identifies a span within a trace as defined by W3C Trace Context
+type SpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	Sampled    bool
+	TraceState string
+}
+
+// IsValid reports whether the trace and span IDs are set
+func (s SpanContext) IsValid() bool {
+	return s.TraceID != [16]byte{} && s.SpanID != [8]byte{}
+}
+
+// Traceparent formats the span context as a traceparent header value
+func (s SpanContext) Traceparent() string {
+	flags := "00"
+	if s.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + hex.EncodeToString(s.TraceID[:]) + "-" + hex.EncodeToString(s.SpanID[:]) + "-" + flags
+}
+
+// ParseTraceparent parses a traceparent header value along with its tracestate companion
+func ParseTraceparent(traceparent, tracestate string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 ||
+		len(parts[3]) != 2 || (parts[0] == "00" && len(parts) != 4) {
+		return SpanContext{}, errors.New("invalid traceparent")
+	}
+
+	spanContext := SpanContext{TraceState: tracestate}
+
+	if _, err := hex.Decode(spanContext.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, errors.New("invalid traceparent")
+	}
+
+	if _, err := hex.Decode(spanContext.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, errors.New("invalid traceparent")
+	}
+
+	flags, err := strconv.ParseUint(parts[3], 16, 8)
+	if err != nil || !spanContext.IsValid() {
+		return SpanContext{}, errors.New("invalid traceparent")
+	}
+	spanContext.Sampled = flags&1 == 1
+
+	return spanContext, nil
+}
+
+// Span is a single traced operation
+type Span interface {
+	// SpanContext returns the identity of the span, used to propagate it to the server
+	SpanContext() SpanContext
+	// SetAttribute records an attribute on the span
+	SetAttribute(key string, value interface{})
+	// SetError marks the span as failed
+	SetError(err error)
+	// End completes the span
+	End()
+}
+
+// Tracer starts spans. Implementing it on top of an OpenTelemetry tracer only takes a small adapter
+type Tracer interface {
+	// Start starts a span named name as a child of the span in ctx, if any, and returns a context holding it
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// spanContextKey is the context key under which the SpanContext of the current span is stored
+type spanContextKey struct{}
+
+// ContextWithSpanContext returns a copy of ctx whose spans become children of spanContext, for example a span
+// received from an upstream service through ParseTraceparent
+func ContextWithSpanContext(ctx context.Context, spanContext SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, spanContext)
+}
+
+// SpanContextFromContext returns the SpanContext stored in ctx by ContextWithSpanContext or by the built-in Tracer
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	spanContext, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return spanContext, ok
+}
+
+// SpanData is a finished span of the built-in Tracer
+type SpanData struct {
+	Name         string
+	SpanContext  SpanContext
+	ParentSpanID [8]byte
+	Attributes   map[string]interface{}
+	Err          error
+	StartTime    time.Time
+	EndTime      time.Time
+}
+
+// SpanExporter receives the spans of the built-in Tracer once they end
+type SpanExporter interface {
+	ExportSpan(span SpanData)
+}
+
+// InMemoryExporter is a SpanExporter keeping spans in memory, meant for tests
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewInMemoryExporter creates an empty InMemoryExporter
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+// ExportSpan implements SpanExporter
+func (e *InMemoryExporter) ExportSpan(span SpanData) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the spans exported so far, in the order they ended
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	spans := make([]SpanData, len(e.spans))
+	copy(spans, e.spans)
+
+	return spans
+}
+
+// Reset drops the spans exported so far
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = nil
+}
+
+// tracer is the built-in Tracer
+type tracer struct {
+	exporter SpanExporter
+}
+
+// NewTracer creates a Tracer which sends every finished span to exporter
+func NewTracer(exporter SpanExporter) Tracer {
+	return &tracer{exporter: exporter}
+}
+
+// Start implements Tracer
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &span{
+		exporter: t.exporter,
+		data: SpanData{
+			Name:       name,
+			Attributes: make(map[string]interface{}),
+			StartTime:  time.Now(),
+		},
+	}
+
+	if parent, ok := SpanContextFromContext(ctx); ok && parent.IsValid() {
+		span.data.SpanContext.TraceID = parent.TraceID
+		span.data.SpanContext.Sampled = parent.Sampled
+		span.data.SpanContext.TraceState = parent.TraceState
+		span.data.ParentSpanID = parent.SpanID
+	} else {
+		rand.Read(span.data.SpanContext.TraceID[:])
+		span.data.SpanContext.Sampled = true
+	}
+	rand.Read(span.data.SpanContext.SpanID[:])
+
+	return ContextWithSpanContext(ctx, span.data.SpanContext), span
+}
+
+// span is a Span of the built-in Tracer
+type span struct {
+	mu       sync.Mutex
+	exporter SpanExporter
+	data     SpanData
+	ended    bool
+}
+
+// SpanContext implements Span
+func (s *span) SpanContext() SpanContext {
+	return s.data.SpanContext
+}
+
+// SetAttribute implements Span
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Attributes[key] = value
+}
+
+// SetError implements Span
+func (s *span) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Err = err
+}
+
+// End implements Span
+func (s *span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	if data.SpanContext.Sampled {
+		s.exporter.ExportSpan(data)
+	}
+}
+
+// setRequestAttributes records the HTTP semantic convention attributes of a request on span
+func setRequestAttributes(span Span, method string, requestURL *url.URL) {
+	span.SetAttribute("http.request.method", method)
+	span.SetAttribute("url.full", requestURL.Redacted())
+	span.SetAttribute("server.address", requestURL.Hostname())
+
+	if port := requestURL.Port(); port != "" {
+		if portNumber, err := strconv.Atoi(port); err == nil {
+			span.SetAttribute("server.port", portNumber)
+		}
+	} else if requestURL.Scheme == "https" {
+		span.SetAttribute("server.port", 443)
+	} else {
+		span.SetAttribute("server.port", 80)
+	}
+}
+
+// setResponseAttributes records the outcome of a request on span, 4xx and 5xx statuses count as errors for clients
+func setResponseAttributes(span Span, response *http.Response, err error) {
+	if err != nil {
+		span.SetAttribute("error.type", fmt.Sprintf("%T", err))
+		span.SetError(err)
+		return
+	}
+
+	span.SetAttribute("http.response.status_code", response.StatusCode)
+	if response.StatusCode >= http.StatusBadRequest {
+		span.SetAttribute("error.type", strconv.Itoa(response.StatusCode))
+		span.SetError(errors.New(response.Status))
+	}
+}
+
+// tracingTransport creates a span for every attempt sent over the network and propagates it to the server through
+// the traceparent and tracestate headers
+type tracingTransport struct {
+	next   http.RoundTripper
+	tracer Tracer
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *tracingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	attempt := stateFromContext(request.Context()).currentAttempt()
+
+	ctx, span := t.tracer.Start(request.Context(), fmt.Sprintf("HTTP %s attempt %d", request.Method, attempt))
+	setRequestAttributes(span, request.Method, request.URL)
+	if attempt > 1 {
+		span.SetAttribute("http.request.resend_count", attempt-1)
+	}
+
+	traced := request.Clone(ctx)
+	if spanContext := span.SpanContext(); spanContext.IsValid() {
+		traced.Header.Set("Traceparent", spanContext.Traceparent())
+		if spanContext.TraceState != "" {
+			traced.Header.Set("Tracestate", spanContext.TraceState)
+		}
+	}
+
+	response, err := t.next.RoundTrip(traced)
+	setResponseAttributes(span, response, err)
+
+	if err != nil {
+		span.End()
+		return nil, err
+	}
+
+	response.Body = newCallbackBody(response.Body, span.End)
+
+	return response, nil
+}
+
+// startCallSpan starts the span covering every attempt of a call to the Client, when tracing is enabled
+func (c *Client) startCallSpan(ctx context.Context, method, rawURL string) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, nil
+	}
+
+	ctx, span := c.tracer.Start(ctx, "HTTP "+method)
+	if requestURL, err := url.Parse(rawURL); err == nil {
+		setRequestAttributes(span, method, requestURL)
+	}
+
+	return ctx, span
+}
+
+// SetTracer makes the Client trace every call with tracer. Each call gets a client span with a child span for every
+// attempt, retries and redirects included, carrying the HTTP semantic convention attributes. The attempt span is
+// propagated to the server with the W3C traceparent and tracestate headers. A nil tracer disables tracing
+func (c *Client) SetTracer(tracer Tracer) {
+	c.tracer = tracer
+}
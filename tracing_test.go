@@ -0,0 +1,107 @@
+package requestor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClient_SetTracer(t *testing.T) {
+	var hits int32
+	var traceparent, tracestate atomic.Value
+	testServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			conn, _, _ := writer.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+
+		traceparent.Store(request.Header.Get("Traceparent"))
+		tracestate.Store(request.Header.Get("Tracestate"))
+	}))
+	defer testServer.Close()
+
+	parent, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "vendor=value")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	exporter := NewInMemoryExporter()
+	client := New()
+	client.SetMaxRetries(2, 0)
+	client.TimeBetweenRetries = 0
+	client.SetTracer(NewTracer(exporter))
+	client.SetContext(ContextWithSpanContext(context.Background(), parent))
+
+	resp, err := client.Get(testServer.URL, nil, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	readBody(t, resp)
+
+	spans := exporter.Spans()
+	if len(spans) != 3 {
+		t.Errorf("Expected: %d \n Got: %d", 3, len(spans))
+		return
+	}
+
+	failed, call, succeeded := spans[0], spans[1], spans[2]
+
+	if call.Name != "HTTP GET" || call.ParentSpanID != parent.SpanID || call.Attributes["http.response.status_code"] != http.StatusOK {
+		t.Errorf("Unexpected call span %+v", call)
+	}
+
+	if failed.Name != "HTTP GET attempt 1" || failed.Err == nil || failed.ParentSpanID != call.SpanContext.SpanID {
+		t.Errorf("Unexpected first attempt span %+v", failed)
+	}
+
+	if succeeded.Name != "HTTP GET attempt 2" || succeeded.ParentSpanID != call.SpanContext.SpanID ||
+		succeeded.Attributes["http.request.resend_count"] != 1 || succeeded.Attributes["http.request.method"] != http.MethodGet {
+		t.Errorf("Unexpected second attempt span %+v", succeeded)
+	}
+
+	for _, span := range spans {
+		if span.SpanContext.TraceID != parent.TraceID {
+			t.Errorf("Expected span %s to belong to the parent trace", span.Name)
+		}
+	}
+
+	if got := traceparent.Load(); got != succeeded.SpanContext.Traceparent() {
+		t.Errorf("Expected: %s \n Got: %s", succeeded.SpanContext.Traceparent(), got)
+	}
+
+	if got := tracestate.Load(); got != "vendor=value" {
+		t.Errorf("Expected: %s \n Got: %s", "vendor=value", got)
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	testCases := []struct {
+		traceparent string
+		valid       bool
+		sampled     bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false, false},
+		{"00-xyz92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+	}
+
+	for _, testCase := range testCases {
+		spanContext, err := ParseTraceparent(testCase.traceparent, "")
+		if (err == nil) != testCase.valid {
+			t.Errorf("%s: Expected valid: %t \n Got: %v", testCase.traceparent, testCase.valid, err)
+			continue
+		}
+
+		if testCase.valid && (spanContext.Sampled != testCase.sampled || spanContext.Traceparent() != testCase.traceparent) {
+			t.Errorf("Expected: %s \n Got: %s", testCase.traceparent, spanContext.Traceparent())
+		}
+	}
+}